@@ -0,0 +1,211 @@
+package cddadb
+
+import (
+	"sort"
+)
+
+// Compatibility is what a gun, or a tool that takes ammo, can be loaded and
+// modded with. The first configuration is the item as it comes; every mod
+// that changes the ammo it takes or the magazines it accepts adds another.
+type Compatibility struct {
+	Item           string               `json:"item"`
+	Name           string               `json:"name"`
+	Type           string               `json:"type"`
+	Mods           []*CompatibleMod     `json:"mods"`
+	Configurations []*AmmoConfiguration `json:"configurations"`
+}
+
+// CompatibleMod is a gunmod or toolmod that fits the item.
+type CompatibleMod struct {
+	ID              string              `json:"id"`
+	Name            string              `json:"name"`
+	Type            string              `json:"type"`
+	Location        string              `json:"location,omitempty"`
+	AmmoModifier    []string            `json:"ammo_modifier,omitempty"`
+	MagazineAdaptor map[string][]string `json:"magazine_adaptor,omitempty"`
+}
+
+// AmmoConfiguration is the ammo and magazines the item takes with Mod
+// installed, or as it comes when Mod is empty, and the ballistics of every
+// round it can fire.
+type AmmoConfiguration struct {
+	Mod       string        `json:"mod,omitempty"`
+	AmmoTypes []string      `json:"ammo_types"`
+	Magazines []string      `json:"magazines"`
+	Ammo      []*Ballistics `json:"ammo"`
+}
+
+// Ballistics is how a round performs fired from the item, adding up the
+// gun, the ammo and the mod of the configuration as the game's gun_damage,
+// gun_range, gun_dispersion and gun_recoil do for an undamaged gun.
+type Ballistics struct {
+	Ammo       string  `json:"ammo"`
+	AmmoType   string  `json:"ammo_type"`
+	Damage     float64 `json:"damage"`
+	Pierce     int     `json:"pierce"`
+	Range      int     `json:"range"`
+	Dispersion int     `json:"dispersion"`
+	Recoil     int     `json:"recoil"`
+}
+
+// GunCompatibility works out what fits an item from every ammo, magazine and
+// mod item type in the dataset, nil if the item takes no ammo.
+func GunCompatibility(item *ItemType, candidates []*ItemType) *Compatibility {
+	ammoTypes, magazines := loading(item)
+	if len(ammoTypes) == 0 {
+		return nil
+	}
+
+	c := &Compatibility{
+		Item:           item.ID,
+		Name:           item.Name.Str,
+		Type:           item.Type,
+		Mods:           []*CompatibleMod{},
+		Configurations: []*AmmoConfiguration{},
+	}
+
+	ammo := []*ItemType{}
+	mods := []*ItemType{}
+	for _, t := range candidates {
+		switch {
+		case t.AmmoSlot != nil:
+			ammo = append(ammo, t)
+		case t.ModSlot != nil && fits(item, t):
+			mods = append(mods, t)
+		}
+	}
+	sort.Slice(ammo, func(i, j int) bool { return ammo[i].ID < ammo[j].ID })
+	sort.Slice(mods, func(i, j int) bool { return mods[i].ID < mods[j].ID })
+
+	c.Configurations = append(c.Configurations, configure(item, nil, ammoTypes, magazines, ammo))
+	for _, m := range mods {
+		cm := &CompatibleMod{
+			ID:              m.ID,
+			Name:            m.Name.Str,
+			Type:            m.Type,
+			AmmoModifier:    m.ModSlot.AmmoModifier,
+			MagazineAdaptor: m.ModSlot.MagazineAdaptor,
+		}
+		if m.GunmodSlot != nil {
+			cm.Location = m.GunmodSlot.Location
+		}
+		c.Mods = append(c.Mods, cm)
+
+		if len(m.ModSlot.AmmoModifier) == 0 && len(m.ModSlot.MagazineAdaptor) == 0 {
+			continue
+		}
+		types := ammoTypes
+		if len(m.ModSlot.AmmoModifier) > 0 {
+			types = m.ModSlot.AmmoModifier
+		}
+		mags := magazines
+		if len(m.ModSlot.MagazineAdaptor) > 0 {
+			mags = m.ModSlot.MagazineAdaptor
+		}
+		c.Configurations = append(c.Configurations, configure(item, m, types, mags, ammo))
+	}
+	return c
+}
+
+// loading is the ammo an item takes and the magazines it accepts for each.
+func loading(item *ItemType) ([]string, AmmoMagazines) {
+	switch {
+	case item.GunSlot != nil:
+		return item.GunSlot.Ammo, item.Magazines
+	case item.ToolSlot != nil:
+		return item.ToolSlot.Ammo, item.Magazines
+	}
+	return nil, nil
+}
+
+// fits reports whether a mod can be installed on the item. A gunmod has to
+// target the gun's skill or the gun itself and go in one of its mod
+// locations, a toolmod has to go on a tool. Either has to accept the ammo
+// the item takes, when it names any.
+func fits(item *ItemType, mod *ItemType) bool {
+	switch {
+	case item.GunSlot != nil && mod.GunmodSlot != nil:
+		g, m := item.GunSlot, mod.GunmodSlot
+		if !hasTag(m.ModTargets, g.Skill) && !hasTag(m.ModTargets, item.ID) {
+			return false
+		}
+		if _, ok := g.ValidModLocations[m.Location]; !ok {
+			return false
+		}
+	case item.ToolSlot != nil && mod.Type == "TOOLMOD":
+	default:
+		return false
+	}
+
+	if len(mod.ModSlot.AcceptableAmmo) == 0 {
+		return true
+	}
+	ammoTypes, _ := loading(item)
+	for _, a := range ammoTypes {
+		if hasTag(mod.ModSlot.AcceptableAmmo, a) {
+			return true
+		}
+	}
+	return false
+}
+
+func configure(item *ItemType, mod *ItemType, types []string, magazines AmmoMagazines, ammo []*ItemType) *AmmoConfiguration {
+	c := &AmmoConfiguration{AmmoTypes: nonNil(types), Magazines: []string{}, Ammo: []*Ballistics{}}
+	if mod != nil {
+		c.Mod = mod.ID
+	}
+
+	seen := make(map[string]bool)
+	for _, t := range types {
+		for _, m := range magazines[t] {
+			if !seen[m] {
+				seen[m] = true
+				c.Magazines = append(c.Magazines, m)
+			}
+		}
+	}
+
+	for _, a := range ammo {
+		for _, t := range a.AmmoSlot.AmmoType {
+			if hasTag(types, t) {
+				c.Ammo = append(c.Ammo, ballistics(item, mod, a, t))
+				break
+			}
+		}
+	}
+	sort.SliceStable(c.Ammo, func(i, j int) bool { return c.Ammo[i].Damage > c.Ammo[j].Damage })
+	return c
+}
+
+func ballistics(item *ItemType, mod *ItemType, ammo *ItemType, ammoType string) *Ballistics {
+	a := ammo.AmmoSlot
+	b := &Ballistics{
+		Ammo:       ammo.ID,
+		AmmoType:   ammoType,
+		Damage:     a.Damage.Total(),
+		Pierce:     a.Pierce,
+		Range:      a.Range,
+		Dispersion: a.Dispersion,
+		Recoil:     a.Recoil,
+	}
+	if g := item.GunSlot; g != nil {
+		b.Damage += g.RangedDamage.Total()
+		b.Pierce += g.Pierce
+		b.Range += g.Range
+		b.Dispersion += g.Dispersion
+		b.Recoil += g.Recoil
+	}
+	if mod != nil && mod.GunmodSlot != nil {
+		m := mod.GunmodSlot
+		b.Damage += m.DamageModifier.Total()
+		b.Range += m.RangeModifier
+		b.Dispersion += m.DispersionModifier
+	}
+	if b.Damage < 0 {
+		b.Damage = 0
+	}
+	b.Range = maxInt(0, b.Range)
+	b.Dispersion = maxInt(0, b.Dispersion)
+	b.Recoil = maxInt(0, b.Recoil)
+	return b
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	_ "github.com/lib/pq"
+	"github.com/ralreegorganon/cddadb/gettext"
+	"github.com/ralreegorganon/cddadb/mods"
+)
+
+// stringList is a flag.Value that accepts a comma separated list and can be
+// repeated on the command line.
+type stringList []string
+
+func (s *stringList) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *stringList) Set(value string) error {
+	for _, v := range strings.Split(value, ",") {
+		v = strings.TrimSpace(v)
+		if v != "" {
+			*s = append(*s, v)
+		}
+	}
+	return nil
+}
+
+func envList(key string) stringList {
+	var s stringList
+	s.Set(os.Getenv(key))
+	return s
+}
+
+type config struct {
+	connectionString string
+	gameRoot         string
+	modRoots         stringList
+	mods             stringList
+	save             string
+	version          string
+	commit           string
+	langs            stringList
+}
+
+// registerDB adds the flags needed to reach the target database.
+func (c *config) registerDB(fs *flag.FlagSet) {
+	fs.StringVar(&c.connectionString, "db", os.Getenv("CDDADB_CONNECTION_STRING"), "Postgres connection string (env CDDADB_CONNECTION_STRING)")
+}
+
+// registerData adds the flags needed to find the game data on disk.
+func (c *config) registerData(fs *flag.FlagSet) {
+	fs.StringVar(&c.gameRoot, "root", os.Getenv("CDDADB_GAME_ROOT"), "path to a Cataclysm-DDA checkout or install (env CDDADB_GAME_ROOT)")
+	c.modRoots = envList("CDDADB_MOD_ROOTS")
+	fs.Var(&c.modRoots, "mod-roots", "comma separated directories to look for mods in besides the game's own (env CDDADB_MOD_ROOTS)")
+	c.mods = envList("CDDADB_MODS")
+	fs.Var(&c.mods, "mods", "comma separated ids of the mods to load on top of the core data (env CDDADB_MODS)")
+	fs.StringVar(&c.save, "save", os.Getenv("CDDADB_SAVE"), "load the mods listed in this save's mods.json instead of -mods (env CDDADB_SAVE)")
+}
+
+// registerVersion adds the flags that name a loaded dataset.
+func (c *config) registerVersion(fs *flag.FlagSet) {
+	fs.StringVar(&c.version, "version", os.Getenv("CDDADB_VERSION"), "name of the dataset, like a release tag, defaults to the commit (env CDDADB_VERSION)")
+	fs.StringVar(&c.commit, "commit", os.Getenv("CDDADB_COMMIT"), "commit the data came from, defaults to the checkout's HEAD (env CDDADB_COMMIT)")
+}
+
+// registerLangs adds the flag that picks the translations to load.
+func (c *config) registerLangs(fs *flag.FlagSet) {
+	c.langs = envList("CDDADB_LANGS")
+	fs.Var(&c.langs, "langs", "comma separated languages to load translations for, like de,ru,zh_CN (env CDDADB_LANGS)")
+}
+
+func (c *config) openDB() (*sqlx.DB, error) {
+	if c.connectionString == "" {
+		return nil, errors.New("no database given, use -db or CDDADB_CONNECTION_STRING")
+	}
+	return sqlx.Open("postgres", c.connectionString)
+}
+
+// loadOrder works out which mods to load, and in what order, from the mods
+// or save given.
+func (c *config) loadOrder() ([]*mods.Mod, error) {
+	root, err := c.jsonRoot()
+	if err != nil {
+		return nil, err
+	}
+
+	roots := []string{}
+	if gameMods := filepath.Join(c.gameRoot, "data", "mods"); exists(gameMods) {
+		roots = append(roots, gameMods)
+	}
+	roots = append(roots, c.modRoots...)
+	available, err := mods.Discover(root, roots...)
+	if err != nil {
+		return nil, err
+	}
+
+	requested := []string(c.mods)
+	if c.save != "" {
+		if requested, err = mods.FromSave(c.save); err != nil {
+			return nil, err
+		}
+	}
+	return mods.LoadOrder(available, requested)
+}
+
+// dataset fills in the commit and version from the checkout when they
+// weren't given.
+func (c *config) dataset() error {
+	if c.commit == "" {
+		out, err := exec.Command("git", "-C", c.gameRoot, "rev-parse", "HEAD").Output()
+		if err == nil {
+			c.commit = strings.TrimSpace(string(out))
+		}
+	}
+	if c.version == "" {
+		c.version = c.commit
+	}
+	if c.version == "" {
+		return errors.New("no version given and the game root isn't a git checkout, use -version or CDDADB_VERSION")
+	}
+	return nil
+}
+
+func (c *config) jsonRoot() (string, error) {
+	if c.gameRoot == "" {
+		return "", errors.New("no game root given, use -root or CDDADB_GAME_ROOT")
+	}
+	root := filepath.Join(c.gameRoot, "data", "json")
+	if _, err := os.Stat(root); err != nil {
+		return "", err
+	}
+	return root, nil
+}
+
+// catalogs reads the translations of each language asked for, preferring
+// the compiled catalog of an install to the .po file of a checkout.
+func (c *config) catalogs() (map[string]*gettext.Catalog, error) {
+	catalogs := make(map[string]*gettext.Catalog)
+	for _, lang := range c.langs {
+		path := filepath.Join(c.gameRoot, "lang", "mo", lang, "LC_MESSAGES", "cataclysm-dda.mo")
+		if !exists(path) {
+			path = filepath.Join(c.gameRoot, "lang", "po", lang+".po")
+		}
+		if !exists(path) {
+			return nil, fmt.Errorf("no translations for %s in %s", lang, filepath.Join(c.gameRoot, "lang"))
+		}
+		catalog, err := gettext.Load(path)
+		if err != nil {
+			return nil, err
+		}
+		catalogs[lang] = catalog
+	}
+	return catalogs, nil
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
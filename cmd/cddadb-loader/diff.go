@@ -0,0 +1,128 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/ralreegorganon/cddadb"
+)
+
+func runDiff(args []string) error {
+	var from, to, c config
+	var asJSON bool
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	fs.StringVar(&from.gameRoot, "from", "", "path to the older game checkout")
+	fs.StringVar(&to.gameRoot, "to", "", "path to the newer game checkout")
+	fs.StringVar(&from.version, "from-version", "", "older loaded dataset to compare instead of a checkout")
+	fs.StringVar(&to.version, "to-version", "", "newer loaded dataset to compare instead of a checkout")
+	fs.BoolVar(&asJSON, "json", false, "print the diff as JSON")
+	c.registerDB(fs)
+	fs.Parse(args)
+
+	var diff *cddadb.Diff
+	var err error
+	switch {
+	case from.version != "" && to.version != "":
+		diff, err = diffDatasets(&c, from.version, to.version)
+	case from.gameRoot != "" && to.gameRoot != "":
+		diff, err = diffCheckouts(&from, &to)
+	default:
+		return errors.New("diff needs both -from and -to, or both -from-version and -to-version")
+	}
+	if err != nil {
+		return err
+	}
+
+	if asJSON {
+		e := json.NewEncoder(os.Stdout)
+		e.SetIndent("", "  ")
+		return e.Encode(diff)
+	}
+
+	for _, t := range diff.Types {
+		for _, id := range t.Added {
+			fmt.Printf("+ %s/%s\n", t.Type, id)
+		}
+		for _, id := range t.Removed {
+			fmt.Printf("- %s/%s\n", t.Type, id)
+		}
+		for _, m := range t.Modified {
+			fmt.Printf("~ %s/%s\n", t.Type, m.ID)
+			for _, f := range m.Fields {
+				fmt.Printf("    %s: %s -> %s\n", f.Path, show(f.From), show(f.To))
+			}
+		}
+	}
+
+	return nil
+}
+
+func diffDatasets(c *config, from, to string) (*cddadb.Diff, error) {
+	db, err := c.openDB()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	return (&cddadb.DB{DB: db}).GetDiff(from, to)
+}
+
+func diffCheckouts(from, to *config) (*cddadb.Diff, error) {
+	before, err := from.objects()
+	if err != nil {
+		return nil, err
+	}
+	resolve(before)
+	after, err := to.objects()
+	if err != nil {
+		return nil, err
+	}
+	resolve(after)
+
+	prev := keyed(before)
+	next := keyed(after)
+
+	pairs := []cddadb.ObjectPair{}
+	for k, o := range prev {
+		p := cddadb.ObjectPair{Type: o.Type, ID: o.key(), From: o.data()}
+		if n, ok := next[k]; ok {
+			p.To = n.data()
+		}
+		pairs = append(pairs, p)
+	}
+	for k, n := range next {
+		if _, ok := prev[k]; !ok {
+			pairs = append(pairs, cddadb.ObjectPair{Type: n.Type, ID: n.key(), To: n.data()})
+		}
+	}
+
+	return cddadb.BuildDiff(from.gameRoot, to.gameRoot, pairs), nil
+}
+
+// keyed indexes objects by type and key, with later definitions replacing
+// earlier ones. Objects without a key can't be matched up across trees and
+// are left out.
+func keyed(objects []object) map[string]object {
+	m := make(map[string]object, len(objects))
+	for _, o := range objects {
+		if o.key() == "" {
+			continue
+		}
+		m[o.Type+"/"+o.key()] = o
+	}
+	return m
+}
+
+func show(v interface{}) string {
+	if v == nil {
+		return "(none)"
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
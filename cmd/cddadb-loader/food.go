@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/json"
+	"math"
+
+	"github.com/ralreegorganon/cddadb"
+)
+
+// kcalPerNutrition converts the nutrition older comestibles give into
+// calories, as the game does when a comestible has no calories of its own.
+const kcalPerNutrition = 2500.0 / (12 * 24)
+
+// comestible is the comestible slot of a food, drink or medicine pulled out
+// into the columns of the comestible table. Weight is per charge, Volume is
+// for the whole of Charges.
+type comestible struct {
+	ID                 string
+	Mod                string
+	Name               string
+	ComestibleType     string
+	Charges            int
+	Calories           int
+	Quench             int
+	Fun                int
+	Stim               int
+	Healthy            int
+	Parasites          int
+	Radiation          int
+	AddictionType      string
+	AddictionPotential int
+	SpoilsIn           *int64
+	Weight             *int64
+	Volume             *int64
+	Materials          []string
+	Vitamins           map[string]int
+}
+
+// comestibles reads the comestibles in effect once every mod has been
+// loaded, inheritance already applied by the resolver.
+func comestibles(objects []object) []*comestible {
+	effective := make(map[string]*object)
+	order := []string{}
+	for i := range objects {
+		o := &objects[i]
+		if o.Type != "COMESTIBLE" || o.ID == "" || o.Resolved == nil {
+			continue
+		}
+		if _, ok := effective[o.ID]; !ok {
+			order = append(order, o.ID)
+		}
+		effective[o.ID] = o
+	}
+
+	cs := []*comestible{}
+	for _, id := range order {
+		o := effective[id]
+		b, err := json.Marshal(o.Resolved)
+		if err != nil {
+			continue
+		}
+		t, err := cddadb.DecodeItemType(b)
+		if err != nil || t.ComestibleSlot == nil {
+			// validateItems reports items that don't decode.
+			continue
+		}
+		s := t.ComestibleSlot
+
+		c := &comestible{
+			ID:                 id,
+			Mod:                o.Mod,
+			Name:               t.Name.Str,
+			ComestibleType:     s.ComestibleType,
+			Charges:            s.Charges,
+			Quench:             s.Quench,
+			Fun:                s.Fun,
+			Stim:               s.Stim,
+			Healthy:            s.Healthy,
+			Parasites:          s.Parasites,
+			Radiation:          s.Radiation,
+			AddictionType:      s.AddictionType,
+			AddictionPotential: s.AddictionPotential,
+			Materials:          []string(t.Material),
+			Vitamins:           map[string]int(s.Vitamins),
+		}
+		if c.Charges < 1 {
+			c.Charges = 1
+		}
+		if s.Calories != nil {
+			c.Calories = *s.Calories
+		} else {
+			c.Calories = int(math.Round(float64(s.Nutrition) * kcalPerNutrition))
+		}
+		// Food that doesn't spoil has no spoil time rather than one of zero.
+		if s.SpoilsIn != nil && *s.SpoilsIn > 0 {
+			c.SpoilsIn = int64p(int64(*s.SpoilsIn))
+		}
+		if t.Weight != nil {
+			c.Weight = int64p(int64(*t.Weight))
+		}
+		if t.Volume != nil {
+			c.Volume = int64p(int64(*t.Volume))
+		}
+		if c.Materials == nil {
+			c.Materials = []string{}
+		}
+		if c.Vitamins == nil {
+			c.Vitamins = map[string]int{}
+		}
+		cs = append(cs, c)
+	}
+	return cs
+}
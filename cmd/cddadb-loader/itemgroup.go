@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/ralreegorganon/cddadb"
+)
+
+// itemGroupMember is an item or group that an item group can spawn directly.
+type itemGroupMember struct {
+	Group string
+	Type  string
+	ID    string
+}
+
+// itemGroupMembers lists the direct members of every item group in effect
+// once all mods have loaded, without repeats.
+func itemGroupMembers(objects []object) ([]itemGroupMember, []finding) {
+	effective := make(map[string]*object)
+	order := []string{}
+	for i := range objects {
+		o := &objects[i]
+		if o.Type != "item_group" || o.ID == "" || o.Resolved == nil {
+			continue
+		}
+		if _, ok := effective[o.ID]; !ok {
+			order = append(order, o.ID)
+		}
+		effective[o.ID] = o
+	}
+
+	members := []itemGroupMember{}
+	findings := []finding{}
+	for _, id := range order {
+		o := effective[id]
+		b, err := json.Marshal(o.Resolved)
+		if err != nil {
+			findings = append(findings, finding{Source: o.Source, ID: id, Message: err.Error()})
+			continue
+		}
+		g, err := cddadb.ParseItemGroup(b)
+		if err != nil {
+			findings = append(findings, finding{Source: o.Source, ID: id, Message: err.Error()})
+			continue
+		}
+
+		seen := make(map[string]bool)
+		items, groups := g.Members()
+		add := func(kind, member string) {
+			k := fmt.Sprintf("%s/%s", kind, member)
+			if member == "" || seen[k] {
+				return
+			}
+			seen[k] = true
+			members = append(members, itemGroupMember{Group: id, Type: kind, ID: member})
+		}
+		for _, i := range items {
+			add("item", i)
+		}
+		for _, g := range groups {
+			add("group", g)
+		}
+	}
+	return members, findings
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ralreegorganon/cddadb"
+)
+
+// inheritanceMembers are the members any definition can use to inherit from
+// another, which no loader in the game reads directly.
+var inheritanceMembers = map[string]bool{
+	"copy-from":    true,
+	"abstract":     true,
+	"relative":     true,
+	"proportional": true,
+	"extend":       true,
+	"delete":       true,
+}
+
+// knownIDs are the ids a definition can refer to, by what kind of thing
+// they name.
+type knownIDs struct {
+	items        map[string]bool
+	groups       map[string]bool
+	requirements map[string]bool
+	ammoTypes    map[string]bool
+}
+
+func knownIDsOf(objects []object) *knownIDs {
+	k := &knownIDs{
+		items:        make(map[string]bool),
+		groups:       make(map[string]bool),
+		requirements: make(map[string]bool),
+		ammoTypes:    make(map[string]bool),
+	}
+	for _, o := range objects {
+		if o.ID == "" {
+			continue
+		}
+		switch {
+		case o.namespace() == "item":
+			k.items[o.ID] = true
+		case o.Type == "item_group":
+			k.groups[o.ID] = true
+		case o.Type == "requirement":
+			k.requirements[o.ID] = true
+		case o.Type == "ammunition_type":
+			k.ammoTypes[o.ID] = true
+		}
+	}
+	return k
+}
+
+// validateReferences checks that the items, item groups, requirements and
+// ammo types definitions name are defined. Definitions are checked as
+// written, so each finding points at the file the reference is in.
+func validateReferences(objects []object) []finding {
+	known := knownIDsOf(objects)
+	findings := []finding{}
+	for i := range objects {
+		o := &objects[i]
+		check := func(kind string, ids map[string]bool, v interface{}, path string) {
+			id, ok := v.(string)
+			if !ok || id == "" || id == "NULL" || ids[id] {
+				return
+			}
+			findings = append(findings, finding{Rule: ruleReference, Source: o.Source, ID: o.key(), Path: path, Message: fmt.Sprintf("unknown %s %s", kind, id)})
+		}
+		items := func(v interface{}, path string) { check("item", known.items, v, path) }
+		groups := func(v interface{}, path string) { check("item group", known.groups, v, path) }
+
+		r := o.Raw
+		switch {
+		case o.Type == "item_group":
+			checkGroupEntries(r, "", items, groups)
+
+		case o.Type == "recipe" || o.Type == "uncraft" || o.Type == "requirement":
+			if o.Type != "requirement" {
+				items(r["result"], "result")
+			}
+			for _, key := range []string{"components", "tools"} {
+				for g, group := range list(r[key]) {
+					for a, alt := range list(group) {
+						path := fmt.Sprintf("%s[%d][%d]", key, g, a)
+						e := list(alt)
+						if e == nil {
+							items(alt, path)
+							continue
+						}
+						if len(e) > 2 && e[2] == "LIST" {
+							check("requirement", known.requirements, e[0], path+"[0]")
+						} else if len(e) > 0 {
+							items(e[0], path+"[0]")
+						}
+					}
+				}
+			}
+			if using, ok := r["using"].(string); ok {
+				check("requirement", known.requirements, using, "using")
+			}
+			for u, use := range list(r["using"]) {
+				if e := list(use); len(e) > 0 {
+					check("requirement", known.requirements, e[0], fmt.Sprintf("using[%d][0]", u))
+				}
+			}
+
+		case o.namespace() == "item":
+			for m, entry := range list(r["magazines"]) {
+				e := list(entry)
+				if len(e) < 2 {
+					continue
+				}
+				if len(known.ammoTypes) > 0 {
+					check("ammo type", known.ammoTypes, e[0], fmt.Sprintf("magazines[%d][0]", m))
+				}
+				for j, mag := range list(e[1]) {
+					items(mag, fmt.Sprintf("magazines[%d][1][%d]", m, j))
+				}
+			}
+			// Older data has no ammunition_type definitions to check against.
+			if len(known.ammoTypes) > 0 {
+				for _, key := range []string{"ammo", "ammo_type"} {
+					if a, ok := r[key].(string); ok {
+						check("ammo type", known.ammoTypes, a, key)
+					}
+					for j, a := range list(r[key]) {
+						check("ammo type", known.ammoTypes, a, fmt.Sprintf("%s[%d]", key, j))
+					}
+				}
+			}
+			items(r["container"], "container")
+
+		case o.Type == "MONSTER":
+			if _, ok := r["death_drops"].(string); ok {
+				groups(r["death_drops"], "death_drops")
+			}
+		}
+	}
+	return findings
+}
+
+// checkGroupEntries checks the items and groups an item group, or an inline
+// group inside one, spawns.
+func checkGroupEntries(g map[string]interface{}, path string, items, groups func(v interface{}, path string)) {
+	at := func(key string, i int) string {
+		return fmt.Sprintf("%s%s[%d]", path, key, i)
+	}
+	for _, key := range []string{"items", "entries"} {
+		for i, e := range list(g[key]) {
+			switch e := e.(type) {
+			case string:
+				items(e, at(key, i))
+			case []interface{}:
+				if len(e) > 0 {
+					items(e[0], at(key, i)+"[0]")
+				}
+			case map[string]interface{}:
+				checkGroupEntry(e, at(key, i)+".", items, groups)
+			}
+		}
+	}
+	for i, e := range list(g["groups"]) {
+		switch e := e.(type) {
+		case string:
+			groups(e, at("groups", i))
+		case []interface{}:
+			if len(e) > 0 {
+				groups(e[0], at("groups", i)+"[0]")
+			}
+		}
+	}
+}
+
+func checkGroupEntry(e map[string]interface{}, path string, items, groups func(v interface{}, path string)) {
+	items(e["item"], path+"item")
+	groups(e["group"], path+"group")
+	items(e["container-item"], path+"container-item")
+	items(e["ammo-item"], path+"ammo-item")
+	groups(e["contents-group"], path+"contents-group")
+	items(e["contents-item"], path+"contents-item")
+	for i, c := range list(e["contents-item"]) {
+		items(c, fmt.Sprintf("%scontents-item[%d]", path, i))
+	}
+	for _, kind := range []string{"distribution", "collection"} {
+		for i, sub := range list(e[kind]) {
+			p := fmt.Sprintf("%s%s[%d]", path, kind, i)
+			switch sub := sub.(type) {
+			case string:
+				items(sub, p)
+			case map[string]interface{}:
+				checkGroupEntry(sub, p+".", items, groups)
+			}
+		}
+	}
+}
+
+// validateMembers reports members of definitions that their type doesn't
+// read, which is most often a misspelling. Types the API doesn't parse aren't
+// checked.
+func validateMembers(objects []object) []finding {
+	findings := []finding{}
+	for _, o := range objects {
+		members, ok := cddadb.Members(o.Type)
+		if !ok {
+			continue
+		}
+		keys := make([]string, 0, len(o.Raw))
+		for k := range o.Raw {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if members[k] || inheritanceMembers[k] || strings.HasPrefix(k, "//") {
+				continue
+			}
+			findings = append(findings, finding{Rule: ruleUnknownMember, Source: o.Source, ID: o.key(), Path: k, Message: fmt.Sprintf("%s doesn't read %s", o.Type, k)})
+		}
+	}
+	return findings
+}
@@ -0,0 +1,263 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"flag"
+
+	"github.com/lib/pq"
+	log "github.com/sirupsen/logrus"
+)
+
+func runLoad(args []string) error {
+	var c config
+	fs := flag.NewFlagSet("load", flag.ExitOnError)
+	c.registerDB(fs)
+	c.registerData(fs)
+	c.registerVersion(fs)
+	c.registerLangs(fs)
+	fs.Parse(args)
+
+	if err := c.dataset(); err != nil {
+		return err
+	}
+
+	order, err := c.loadOrder()
+	if err != nil {
+		return err
+	}
+
+	objects, err := objectsOf(order)
+	if err != nil {
+		return err
+	}
+
+	for _, f := range resolve(objects) {
+		log.WithField("source", f.Source).WithField("id", f.ID).Warn(f.Message)
+	}
+
+	catalogs, err := c.catalogs()
+	if err != nil {
+		return err
+	}
+
+	db, err := c.openDB()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	txn, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer txn.Rollback()
+
+	// Loading a version again replaces it rather than adding to it.
+	if _, err = txn.Exec(`delete from dataset where version = $1`, c.version); err != nil {
+		return err
+	}
+
+	ids := []string{}
+	for _, m := range order {
+		ids = append(ids, m.ID)
+	}
+
+	var dataset int
+	err = txn.QueryRow(`
+		insert into dataset (version, commit, mods)
+		values ($1, $2, $3)
+		returning dataset_id
+	`, c.version, nullString(c.commit), pq.Array(ids)).Scan(&dataset)
+	if err != nil {
+		return err
+	}
+
+	stmt, err := txn.Prepare(pq.CopyIn("game_object", "dataset_id", "abstract", "id", "type", "source", "mod", "raw", "resolved", "weight", "volume", "energy", "spoils_in", "ancestry"))
+	if err != nil {
+		return err
+	}
+
+	for i := range objects {
+		o := &objects[i]
+		m, err := measure(o)
+		if err != nil {
+			log.WithField("source", o.Source).WithField("id", o.key()).Warn(err)
+		}
+		raw, err := json.Marshal(o.Raw)
+		if err != nil {
+			return err
+		}
+		resolved, err := nullJSON(o.Resolved)
+		if err != nil {
+			return err
+		}
+		_, err = stmt.Exec(dataset, nullString(o.Abstract), nullString(o.ID), o.Type, o.Source, o.Mod, string(raw), resolved, m.Weight, m.Volume, m.Energy, m.SpoilsIn, pq.Array(o.Ancestry))
+		if err != nil {
+			return err
+		}
+	}
+
+	if _, err = stmt.Exec(); err != nil {
+		return err
+	}
+	if err = stmt.Close(); err != nil {
+		return err
+	}
+
+	rs, findings := recipes(objects)
+	for _, f := range findings {
+		log.WithField("source", f.Source).WithField("id", f.ID).Warn(f.Message)
+	}
+	installs, findings := vehiclePartInstalls(objects)
+	for _, f := range findings {
+		log.WithField("source", f.Source).WithField("id", f.ID).Warn(f.Message)
+	}
+	for _, f := range validateVehicles(objects) {
+		log.WithField("source", f.Source).WithField("id", f.ID).Warn(f.Message)
+	}
+	if err = loadRecipes(txn, dataset, append(rs, installs...)); err != nil {
+		return err
+	}
+
+	members, findings := itemGroupMembers(objects)
+	for _, f := range findings {
+		log.WithField("source", f.Source).WithField("id", f.ID).Warn(f.Message)
+	}
+	rows := make([][]interface{}, len(members))
+	for i, m := range members {
+		rows[i] = []interface{}{dataset, m.Group, m.Type, m.ID}
+	}
+	if err = copyRows(txn, "item_group_member", []string{"dataset_id", "group_id", "member_type", "member_id"}, rows); err != nil {
+		return err
+	}
+
+	foods := comestibles(objects)
+	rows = make([][]interface{}, len(foods))
+	for i, f := range foods {
+		vitamins, err := json.Marshal(f.Vitamins)
+		if err != nil {
+			return err
+		}
+		rows[i] = []interface{}{dataset, f.ID, f.Mod, f.Name, nullString(f.ComestibleType), f.Charges, f.Calories, f.Quench, f.Fun, f.Stim, f.Healthy, f.Parasites, f.Radiation, nullString(f.AddictionType), f.AddictionPotential, f.SpoilsIn, f.Weight, f.Volume, pq.Array(f.Materials), string(vitamins)}
+	}
+	err = copyRows(txn, "comestible", []string{"dataset_id", "id", "mod", "name", "comestible_type", "charges", "calories", "quench", "fun", "stim", "healthy", "parasites", "radiation", "addiction_type", "addiction_potential", "spoils_in", "weight", "volume", "materials", "vitamins"}, rows)
+	if err != nil {
+		return err
+	}
+
+	rows = [][]interface{}{}
+	for lang, catalog := range catalogs {
+		rows = append(rows, []interface{}{dataset, lang, catalog.NPlurals, catalog.PluralForms})
+	}
+	if err = copyRows(txn, "language", []string{"dataset_id", "lang", "nplurals", "plural_forms"}, rows); err != nil {
+		return err
+	}
+	ts := translations(objects, catalogs)
+	rows = make([][]interface{}, len(ts))
+	for i, t := range ts {
+		rows[i] = []interface{}{dataset, t.Lang, t.Namespace, t.ID, nullString(t.Name), pq.Array(t.NameForms), nullString(t.Description)}
+	}
+	if err = copyRows(txn, "translation", []string{"dataset_id", "lang", "namespace", "id", "name", "name_forms", "description"}, rows); err != nil {
+		return err
+	}
+
+	refs := references(objects)
+	rows = make([][]interface{}, len(refs))
+	for i, r := range refs {
+		rows[i] = []interface{}{dataset, r.Ref, r.Type, nullString(r.ID), r.Source, r.Mod, r.Path}
+	}
+	if err = copyRows(txn, "object_ref", []string{"dataset_id", "ref_id", "object_type", "object_id", "source", "mod", "path"}, rows); err != nil {
+		return err
+	}
+
+	if err = txn.Commit(); err != nil {
+		return err
+	}
+
+	log.WithField("version", c.version).WithField("count", len(objects)).Info("Loaded game objects")
+	return nil
+}
+
+// loadRecipes writes the flattened recipes into the recipe tables.
+func loadRecipes(txn *sql.Tx, dataset int, rs []*recipe) error {
+	definitions := [][]interface{}{}
+	components := [][]interface{}{}
+	tools := [][]interface{}{}
+	qualities := [][]interface{}{}
+	skills := [][]interface{}{}
+	for _, r := range rs {
+		definitions = append(definitions, []interface{}{dataset, r.Type, r.ID, r.Result, r.Makes, nullString(r.Category), nullString(r.Subcategory), nullString(r.SkillUsed), r.Difficulty, r.Time, r.Reversible})
+		for _, c := range r.Components {
+			components = append(components, []interface{}{dataset, r.Type, r.ID, c.Group, c.Alternative, c.ID, c.Count, nullString(c.Via)})
+		}
+		for _, t := range r.Tools {
+			tools = append(tools, []interface{}{dataset, r.Type, r.ID, t.Group, t.Alternative, t.ID, t.Count, nullString(t.Via)})
+		}
+		for _, q := range r.Qualities {
+			qualities = append(qualities, []interface{}{dataset, r.Type, r.ID, q.Group, q.Alternative, q.ID, q.Level, q.Amount, nullString(q.Via)})
+		}
+		for _, s := range r.Skills {
+			skills = append(skills, []interface{}{dataset, r.Type, r.ID, s.ID, s.Level})
+		}
+	}
+
+	err := copyRows(txn, "recipe_definition", []string{"dataset_id", "recipe_type", "recipe_id", "result", "makes", "category", "subcategory", "skill_used", "difficulty", "time", "reversible"}, definitions)
+	if err != nil {
+		return err
+	}
+	err = copyRows(txn, "recipe_component", []string{"dataset_id", "recipe_type", "recipe_id", "group_index", "alternative_index", "item_id", "count", "via"}, components)
+	if err != nil {
+		return err
+	}
+	err = copyRows(txn, "recipe_tool", []string{"dataset_id", "recipe_type", "recipe_id", "group_index", "alternative_index", "item_id", "charges", "via"}, tools)
+	if err != nil {
+		return err
+	}
+	err = copyRows(txn, "recipe_quality", []string{"dataset_id", "recipe_type", "recipe_id", "group_index", "alternative_index", "quality_id", "level", "amount", "via"}, qualities)
+	if err != nil {
+		return err
+	}
+	err = copyRows(txn, "recipe_skill", []string{"dataset_id", "recipe_type", "recipe_id", "skill_id", "level"}, skills)
+	if err != nil {
+		return err
+	}
+
+	log.WithField("count", len(rs)).Info("Loaded recipes")
+	return nil
+}
+
+// copyRows bulk loads rows into a table.
+func copyRows(txn *sql.Tx, table string, columns []string, rows [][]interface{}) error {
+	stmt, err := txn.Prepare(pq.CopyIn(table, columns...))
+	if err != nil {
+		return err
+	}
+	for _, r := range rows {
+		if _, err = stmt.Exec(r...); err != nil {
+			return err
+		}
+	}
+	if _, err = stmt.Exec(); err != nil {
+		return err
+	}
+	return stmt.Close()
+}
+
+func nullString(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+func nullJSON(v map[string]interface{}) (interface{}, error) {
+	if v == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
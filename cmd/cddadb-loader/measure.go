@@ -0,0 +1,90 @@
+package main
+
+import (
+	"github.com/ralreegorganon/cddadb/units"
+)
+
+// measures are the quantities pulled out of a resolved object into numeric
+// columns, each in its canonical unit.
+type measures struct {
+	Weight   *int64
+	Volume   *int64
+	Energy   *int64
+	SpoilsIn *int64
+}
+
+// measure normalizes the quantities the API sorts and filters on. Values
+// written either as bare numbers or with units end up in the same unit.
+func measure(o *object) (measures, error) {
+	var m measures
+	r := o.Resolved
+	if r == nil {
+		return m, nil
+	}
+
+	if o.namespace() == "item" {
+		if v, ok := r["weight"]; ok {
+			w, err := units.ParseMass(v)
+			if err != nil {
+				return m, err
+			}
+			m.Weight = int64p(int64(w))
+		}
+		if v, ok := r["volume"]; ok {
+			vol, err := units.ParseVolume(v)
+			if err != nil {
+				return m, err
+			}
+			m.Volume = int64p(int64(vol))
+		}
+		if v, ok := r["spoils_in"]; ok {
+			d, err := units.ParseDuration(v, units.Hours)
+			if err != nil {
+				return m, err
+			}
+			m.SpoilsIn = int64p(int64(d))
+		}
+	}
+
+	if o.Type == "vehicle_part" {
+		if v, ok := r["size"]; ok {
+			vol, err := units.ParseVolume(v)
+			if err != nil {
+				return m, err
+			}
+			m.Volume = int64p(int64(vol))
+		}
+	}
+
+	if o.Type == "furniture" {
+		if v, ok := r["max_volume"]; ok {
+			vol, err := units.ParseVolume(v)
+			if err != nil {
+				return m, err
+			}
+			m.Volume = int64p(int64(vol))
+		}
+	}
+
+	if o.Type == "bionic" {
+		if v, ok := r["capacity"]; ok {
+			// bare bionic power is in the game's power units, a kilojoule each
+			var e units.Energy
+			if n, isBare := v.(float64); isBare {
+				e = units.Energy(n) * units.Kilojoules
+			} else {
+				var err error
+				if e, err = units.ParseEnergy(v); err != nil {
+					return m, err
+				}
+			}
+			m.Energy = int64p(int64(e))
+		}
+	}
+
+	return m, nil
+}
+
+func int64p(v int64) *int64 {
+	return &v
+}
@@ -0,0 +1,341 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/ralreegorganon/cddadb/units"
+)
+
+// recipe is a recipe or uncraft flattened into the rows of the recipe tables,
+// with every requirement it uses spelled out.
+type recipe struct {
+	Type        string
+	ID          string
+	Result      string
+	Makes       int64
+	Category    string
+	Subcategory string
+	SkillUsed   string
+	Difficulty  int64
+	Time        *int64
+	Reversible  bool
+	Components  []requirementItem
+	Tools       []requirementItem
+	Qualities   []requirementQuality
+	Skills      []requirementSkill
+}
+
+// requirementItem is one alternative of a component or tool group. Count is
+// the number of components, or the charges a tool uses with -1 for none. Via
+// names the requirement the entry was expanded from.
+type requirementItem struct {
+	Group       int
+	Alternative int
+	ID          string
+	Count       int64
+	Via         string
+}
+
+type requirementQuality struct {
+	Group       int
+	Alternative int
+	ID          string
+	Level       int64
+	Amount      int64
+	Via         string
+}
+
+type requirementSkill struct {
+	ID    string
+	Level int64
+}
+
+// maxRequirementDepth bounds how deep requirements can refer to each other,
+// so a requirement that ends up using itself is reported instead of looping.
+const maxRequirementDepth = 8
+
+// recipes flattens the recipes and uncrafts that are in effect once every mod
+// has been loaded, expanding the requirements they use.
+func recipes(objects []object) ([]*recipe, []finding) {
+	requirements := requirementTable(objects)
+	charges := defaultCharges(objects)
+	effective := make(map[string]*object)
+	order := []string{}
+	for i := range objects {
+		o := &objects[i]
+		if o.Resolved == nil {
+			continue
+		}
+		switch o.Type {
+		case "recipe", "uncraft":
+			k := o.Type + "/" + o.ID
+			if _, ok := effective[k]; !ok {
+				order = append(order, k)
+			}
+			effective[k] = o
+		}
+	}
+
+	findings := []finding{}
+	rs := []*recipe{}
+	for _, k := range order {
+		o := effective[k]
+		if o.ID == "" || boolField(o.Resolved, "obsolete") {
+			continue
+		}
+		e := &expander{requirements: requirements}
+		r := &recipe{
+			Type:        o.Type,
+			ID:          o.ID,
+			Result:      stringField(o.Resolved, "result"),
+			Makes:       1,
+			Category:    stringField(o.Resolved, "category"),
+			Subcategory: stringField(o.Resolved, "subcategory"),
+			SkillUsed:   stringField(o.Resolved, "skill_used"),
+			Difficulty:  intField(o.Resolved, "difficulty"),
+			Reversible:  boolField(o.Resolved, "reversible"),
+			Skills:      skills(o.Resolved["skills_required"]),
+		}
+		if m := intField(o.Resolved, "result_mult"); m > 0 {
+			r.Makes = m
+		}
+		if c := charges[r.Result]; c > 0 {
+			r.Makes *= c
+		}
+		if t, ok := o.Resolved["time"]; ok {
+			moves, err := recipeTime(t)
+			if err != nil {
+				e.errs = append(e.errs, fmt.Errorf("time: %v", err))
+			} else {
+				r.Time = &moves
+			}
+		}
+		e.add(r, o.Resolved, "", 1, 0)
+		for _, err := range e.errs {
+			findings = append(findings, finding{Source: o.Source, ID: o.ID, Message: err.Error()})
+		}
+		rs = append(rs, r)
+	}
+	return rs, findings
+}
+
+// requirementTable maps each requirement id to its definition, the last one
+// loaded winning.
+func requirementTable(objects []object) map[string]map[string]interface{} {
+	requirements := make(map[string]map[string]interface{})
+	for _, o := range objects {
+		if o.Type == "requirement" && o.Resolved != nil {
+			requirements[o.ID] = o.Resolved
+		}
+	}
+	return requirements
+}
+
+// defaultCharges maps the items that are counted by charges to the charges
+// one of them is made with, the last definition loaded winning. A recipe for
+// one of these makes that many at a time.
+func defaultCharges(objects []object) map[string]int64 {
+	charges := make(map[string]int64)
+	for _, o := range objects {
+		if o.ID == "" || o.Resolved == nil {
+			continue
+		}
+		var c int64
+		switch o.Type {
+		case "COMESTIBLE":
+			c = intField(o.Resolved, "charges")
+		case "AMMO":
+			c = intField(o.Resolved, "count")
+		default:
+			continue
+		}
+		if c < 1 {
+			c = 1
+		}
+		charges[o.ID] = c
+	}
+	return charges
+}
+
+// recipeTime reads a recipe's time in moves, a hundred to a turn. Bare
+// numbers are already moves.
+func recipeTime(v interface{}) (int64, error) {
+	if n, ok := v.(float64); ok {
+		return int64(n), nil
+	}
+	d, err := units.ParseDuration(v, units.Turns)
+	return int64(d) * 100, err
+}
+
+type expander struct {
+	requirements map[string]map[string]interface{}
+	errs         []error
+}
+
+// add appends the components, tools and qualities of a recipe or requirement
+// to r, scaled by multiplier, then does the same for each requirement it uses.
+func (e *expander) add(r *recipe, d map[string]interface{}, via string, multiplier int64, depth int) {
+	for _, g := range list(d["components"]) {
+		r.Components = append(r.Components, e.group(nextItemGroup(r.Components), g, via, multiplier, "components", depth)...)
+	}
+	for _, g := range list(d["tools"]) {
+		r.Tools = append(r.Tools, e.group(nextItemGroup(r.Tools), g, via, multiplier, "tools", depth)...)
+	}
+	r.Qualities = append(r.Qualities, qualities(nextGroup(r.Qualities), d["qualities"], via)...)
+
+	for _, u := range list(d["using"]) {
+		entry := list(u)
+		if len(entry) < 1 {
+			continue
+		}
+		id, _ := entry[0].(string)
+		n := int64(1)
+		if len(entry) > 1 {
+			n = number(entry[1])
+		}
+		req, ok := e.lookup(id, depth)
+		if !ok {
+			continue
+		}
+		e.add(r, req, id, multiplier*n, depth+1)
+	}
+}
+
+func (e *expander) lookup(id string, depth int) (map[string]interface{}, bool) {
+	if depth >= maxRequirementDepth {
+		e.errs = append(e.errs, fmt.Errorf("requirement %s nests too deeply", id))
+		return nil, false
+	}
+	req, ok := e.requirements[id]
+	if !ok {
+		e.errs = append(e.errs, fmt.Errorf("unknown requirement %s", id))
+	}
+	return req, ok
+}
+
+// group reads one group of alternatives, replacing an entry marked LIST with
+// the alternatives of the first group of the requirement it names.
+func (e *expander) group(index int, g interface{}, via string, multiplier int64, member string, depth int) []requirementItem {
+	items := []requirementItem{}
+	for _, a := range list(g) {
+		entry := list(a)
+		if len(entry) < 1 {
+			continue
+		}
+		id, _ := entry[0].(string)
+		count := int64(1)
+		if len(entry) > 1 {
+			count = number(entry[1])
+		}
+		if count > 0 {
+			count *= multiplier
+		}
+
+		if len(entry) > 2 && entry[2] == "LIST" {
+			req, ok := e.lookup(id, depth)
+			if !ok {
+				continue
+			}
+			groups := list(req[member])
+			if len(groups) == 0 {
+				continue
+			}
+			for _, sub := range e.group(index, groups[0], id, count, member, depth+1) {
+				sub.Alternative = len(items)
+				items = append(items, sub)
+			}
+			continue
+		}
+
+		items = append(items, requirementItem{Group: index, Alternative: len(items), ID: id, Count: count, Via: via})
+	}
+	return items
+}
+
+// nextItemGroup is the group number the next component or tool group should
+// take.
+func nextItemGroup(items []requirementItem) int {
+	if len(items) == 0 {
+		return 0
+	}
+	return items[len(items)-1].Group + 1
+}
+
+// nextGroup is the group number the next quality group should take.
+func nextGroup(qs []requirementQuality) int {
+	if len(qs) == 0 {
+		return 0
+	}
+	return qs[len(qs)-1].Group + 1
+}
+
+// qualities reads a qualities member, where each entry is either a single
+// quality or a list of alternative qualities.
+func qualities(first int, v interface{}, via string) []requirementQuality {
+	qs := []requirementQuality{}
+	for i, e := range list(v) {
+		alternatives := list(e)
+		if m, ok := e.(map[string]interface{}); ok {
+			alternatives = []interface{}{m}
+		}
+		for j, a := range alternatives {
+			m, ok := a.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			amount := intField(m, "amount")
+			if amount == 0 {
+				amount = 1
+			}
+			qs = append(qs, requirementQuality{
+				Group:       first + i,
+				Alternative: j,
+				ID:          stringField(m, "id"),
+				Level:       intField(m, "level"),
+				Amount:      amount,
+				Via:         via,
+			})
+		}
+	}
+	return qs
+}
+
+// skills reads skills_required, which is either a single [skill, level] pair
+// or a list of them.
+func skills(v interface{}) []requirementSkill {
+	entries := list(v)
+	if len(entries) == 2 {
+		if id, ok := entries[0].(string); ok {
+			return []requirementSkill{{ID: id, Level: number(entries[1])}}
+		}
+	}
+	ss := []requirementSkill{}
+	for _, e := range entries {
+		pair := list(e)
+		if len(pair) != 2 {
+			continue
+		}
+		id, _ := pair[0].(string)
+		ss = append(ss, requirementSkill{ID: id, Level: number(pair[1])})
+	}
+	return ss
+}
+
+func list(v interface{}) []interface{} {
+	l, _ := v.([]interface{})
+	return l
+}
+
+func number(v interface{}) int64 {
+	n, _ := v.(float64)
+	return int64(n)
+}
+
+func intField(d map[string]interface{}, key string) int64 {
+	return number(d[key])
+}
+
+func boolField(d map[string]interface{}, key string) bool {
+	b, _ := d[key].(bool)
+	return b
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+)
+
+// reference is a place in an object's definition that names another object.
+// Path is where the id is written, like components[0][1][0].
+type reference struct {
+	Ref    string
+	Type   string
+	ID     string
+	Source string
+	Mod    string
+	Path   string
+}
+
+// refMembers are the top level members that name other objects, with the
+// namespaces, the item namespace or an object type, the ids in each can come
+// from. Strings anywhere else, or that aren't an id in one of those
+// namespaces, are taken as plain text even when some object has that id, as
+// "fire" or "water" often do. copy-from names an object in the same namespace
+// and is handled on its own.
+var refMembers = map[string][]string{
+	// Items, recipes and requirements.
+	"result":          {"item"},
+	"byproducts":      {"item"},
+	"components":      {"item", "requirement"},
+	"tools":           {"item", "requirement"},
+	"using":           {"requirement"},
+	"qualities":       {"tool_quality"},
+	"skill_used":      {"skill"},
+	"skills_required": {"skill"},
+	"book_learn":      {"item"},
+	"magazines":       {"item", "ammunition_type"},
+	"ammo":            {"ammunition_type"},
+	"ammo_type":       {"ammunition_type"},
+	"container":       {"item"},
+	"revert_to":       {"item"},
+	"material":        {"material"},
+	"vitamins":        {"vitamin"},
+	"faults":          {"fault"},
+
+	// Item groups.
+	"items":   {"item", "item_group"},
+	"entries": {"item", "item_group"},
+	"groups":  {"item_group"},
+
+	// Monsters and monster groups.
+	"species":     {"SPECIES"},
+	"death_drops": {"item_group"},
+	"harvest":     {"harvest"},
+	"upgrades":    {"MONSTER", "monstergroup"},
+	"monsters":    {"MONSTER"},
+	"default":     {"MONSTER"},
+
+	// Mutations and bionics.
+	"prereqs":            {"mutation"},
+	"prereqs2":           {"mutation"},
+	"threshreq":          {"mutation"},
+	"cancels":            {"mutation"},
+	"changes_to":         {"mutation"},
+	"leads_to":           {"mutation"},
+	"canceled_mutations": {"mutation"},
+	"included_bionics":   {"bionic"},
+
+	// Terrain and furniture.
+	"bash":            {"item", "item_group", "terrain", "furniture"},
+	"deconstruct":     {"item", "item_group", "terrain", "furniture"},
+	"open":            {"terrain", "furniture"},
+	"close":           {"terrain", "furniture"},
+	"transforms_into": {"terrain", "furniture"},
+	"roof":            {"terrain"},
+
+	// Vehicles.
+	"parts": {"vehicle_part"},
+	"item":  {"item"},
+}
+
+// references finds the ids written in the members of the definitions that
+// name other objects. References are taken from the raw definitions, so each
+// one points at the file it is written in; an object inheriting a member
+// refers to its parent through copy-from instead.
+func references(objects []object) []reference {
+	known := make(map[string]map[string]bool)
+	for _, o := range objects {
+		k := o.key()
+		if k == "" {
+			continue
+		}
+		if known[o.namespace()] == nil {
+			known[o.namespace()] = make(map[string]bool)
+		}
+		known[o.namespace()][k] = true
+	}
+
+	refs := []reference{}
+	for _, o := range objects {
+		self := o.key()
+		seen := make(map[string]bool)
+		add := func(namespaces []string) func(id, path string) {
+			return func(id, path string) {
+				if id == self || seen[id+" "+path] {
+					return
+				}
+				for _, ns := range namespaces {
+					if known[ns][id] {
+						seen[id+" "+path] = true
+						refs = append(refs, reference{Ref: id, Type: o.Type, ID: o.key(), Source: o.Source, Mod: o.Mod, Path: path})
+						return
+					}
+				}
+			}
+		}
+
+		if parent := stringField(o.Raw, "copy-from"); parent != "" {
+			add([]string{o.namespace()})(parent, "copy-from")
+		}
+		keys := make([]string, 0, len(o.Raw))
+		for k := range o.Raw {
+			if refMembers[k] != nil {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			walkStrings(o.Raw[k], k, add(refMembers[k]))
+		}
+	}
+	return refs
+}
+
+// walkStrings calls f with every string in v and the path to it.
+func walkStrings(v interface{}, path string, f func(s, path string)) {
+	switch t := v.(type) {
+	case string:
+		f(t, path)
+	case []interface{}:
+		for i, e := range t {
+			walkStrings(e, fmt.Sprintf("%s[%d]", path, i), f)
+		}
+	case map[string]interface{}:
+		keys := make([]string, 0, len(t))
+		for k := range t {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			walkStrings(t[k], path+"."+k, f)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"net/url"
+	"path/filepath"
+	"strings"
+)
+
+// report is the JSON form of a validation run.
+type report struct {
+	Errors   int       `json:"errors"`
+	Warnings int       `json:"warnings"`
+	Notes    int       `json:"notes"`
+	Findings []finding `json:"findings"`
+}
+
+func jsonReport(findings []finding) *report {
+	r := &report{Findings: findings}
+	for _, f := range findings {
+		switch f.Level {
+		case levelError:
+			r.Errors++
+		case levelWarning:
+			r.Warnings++
+		case levelNote:
+			r.Notes++
+		}
+	}
+	return r
+}
+
+// The subset of SARIF 2.1.0 a validation run is written in, so the findings
+// can be shown by code scanning tools alongside the files they are about.
+
+const (
+	sarifSchema  = "https://raw.githubusercontent.com/oasis-tcs/sarif-spec/master/Schemata/sarif-schema-2.1.0.json"
+	sarifVersion = "2.1.0"
+	// sarifRoot is the base files are given relative to, the game root.
+	sarifRoot = "GAMEROOT"
+)
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool               sarifTool                  `json:"tool"`
+	OriginalURIBaseIDs map[string]sarifArtifactID `json:"originalUriBaseIds,omitempty"`
+	Results            []sarifResult              `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string             `json:"id"`
+	ShortDescription     sarifMessage       `json:"shortDescription"`
+	DefaultConfiguration sarifConfiguration `json:"defaultConfiguration"`
+}
+
+type sarifConfiguration struct {
+	Level string `json:"level"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation  `json:"physicalLocation"`
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations,omitempty"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactID `json:"artifactLocation"`
+}
+
+type sarifArtifactID struct {
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId,omitempty"`
+}
+
+// sarifLogicalLocation names the object a finding is about and where in it,
+// as a fully qualified name like glock_19.magazines[0][1][2].
+type sarifLogicalLocation struct {
+	Name               string `json:"name"`
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+	Kind               string `json:"kind"`
+}
+
+func sarifReport(findings []finding, gameRoot string) *sarifLog {
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           "cddadb-loader",
+			InformationURI: "https://github.com/ralreegorganon/cddadb",
+			Rules:          []sarifRule{},
+		}},
+		Results: []sarifResult{},
+	}
+	for _, r := range rules {
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{
+			ID:                   r.ID,
+			ShortDescription:     sarifMessage{Text: r.Description},
+			DefaultConfiguration: sarifConfiguration{Level: r.Level},
+		})
+	}
+
+	root, err := filepath.Abs(gameRoot)
+	if gameRoot == "" || err != nil {
+		root = ""
+	} else {
+		run.OriginalURIBaseIDs = map[string]sarifArtifactID{
+			sarifRoot: {URI: (&url.URL{Scheme: "file", Path: filepath.ToSlash(root) + "/"}).String()},
+		}
+	}
+
+	for _, f := range findings {
+		l := sarifLocation{PhysicalLocation: sarifPhysicalLocation{ArtifactLocation: artifact(f.Source, root)}}
+		if f.ID != "" {
+			ll := sarifLogicalLocation{Name: f.ID, FullyQualifiedName: f.ID, Kind: "object"}
+			if f.Path != "" {
+				ll = sarifLogicalLocation{Name: f.Path, FullyQualifiedName: qualified(f.ID, f.Path), Kind: "member"}
+			}
+			l.LogicalLocations = []sarifLogicalLocation{ll}
+		}
+		run.Results = append(run.Results, sarifResult{
+			RuleID:    f.Rule,
+			Level:     f.Level,
+			Message:   sarifMessage{Text: f.Message},
+			Locations: []sarifLocation{l},
+		})
+	}
+
+	return &sarifLog{Schema: sarifSchema, Version: sarifVersion, Runs: []sarifRun{run}}
+}
+
+// artifact locates a file relative to the game root when it is inside it.
+func artifact(source, root string) sarifArtifactID {
+	abs, err := filepath.Abs(source)
+	if err != nil {
+		abs = source
+	}
+	if root != "" {
+		if rel, err := filepath.Rel(root, abs); err == nil && !strings.HasPrefix(rel, "..") {
+			return sarifArtifactID{URI: filepath.ToSlash(rel), URIBaseID: sarifRoot}
+		}
+	}
+	return sarifArtifactID{URI: (&url.URL{Scheme: "file", Path: filepath.ToSlash(abs)}).String()}
+}
+
+func qualified(id, path string) string {
+	if strings.HasPrefix(path, "[") {
+		return id + path
+	}
+	return id + "." + path
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"github.com/ralreegorganon/cddadb/inherit"
+)
+
+// nestedKeys lists, per namespace, the members the game loads into an already
+// populated structure rather than replacing outright, so a child only has to
+// give the members it changes.
+var nestedKeys = map[string]map[string]bool{
+	"item": {
+		"ammo_data":      true,
+		"armor_data":     true,
+		"artifact_data":  true,
+		"bionic_data":    true,
+		"book_data":      true,
+		"brewable":       true,
+		"container_data": true,
+		"fuel":           true,
+		"gun_data":       true,
+		"gunmod_data":    true,
+		"seed_data":      true,
+	},
+}
+
+// resolve fills in Resolved for every object by following copy-from chains
+// and applying their modifiers, reporting the objects that couldn't be.
+func resolve(objects []object) []finding {
+	defs := make([]*inherit.Definition, len(objects))
+	for i := range objects {
+		o := &objects[i]
+		defs[i] = &inherit.Definition{
+			Namespace: o.namespace(),
+			Key:       o.key(),
+			Raw:       o.Raw,
+			Defaults:  defaults(o),
+		}
+	}
+
+	r := &inherit.Resolver{
+		Nested: nestedKeys,
+		Finish: func(d *inherit.Definition) {
+			if d.Namespace == "item" {
+				finishItem(d.Raw, d.Resolved)
+			}
+		},
+	}
+	r.Resolve(defs)
+
+	findings := []finding{}
+	for i, d := range defs {
+		o := &objects[i]
+		o.Resolved = d.Resolved
+		o.Ancestry = d.Ancestry
+		if d.Err != nil {
+			findings = append(findings, finding{Source: o.Source, ID: o.key(), Path: "copy-from", Message: d.Err.Error()})
+		}
+	}
+	return findings
+}
+
+func defaults(o *object) map[string]interface{} {
+	if o.namespace() == "item" {
+		return itemDefaults(o)
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/ralreegorganon/cddadb/mods"
+	log "github.com/sirupsen/logrus"
+)
+
+type object struct {
+	ID       string
+	Abstract string
+	Type     string
+	Source   string
+	Mod      string
+	Raw      map[string]interface{}
+	Resolved map[string]interface{}
+	// Ancestry is the copy-from chain, nearest parent first.
+	Ancestry []string
+}
+
+// key is the name other objects use to refer to this one.
+func (o *object) key() string {
+	if o.Abstract != "" {
+		return o.Abstract
+	}
+	return o.ID
+}
+
+// namespace groups the types whose ids share a single lookup table in the
+// game. Every item type is kept in one factory, everything else is looked up
+// by its own type.
+func (o *object) namespace() string {
+	if itemTypes[o.Type] {
+		return "item"
+	}
+	return o.Type
+}
+
+// data is the resolved definition, or the raw one when it couldn't be
+// resolved.
+func (o *object) data() interface{} {
+	if o.Resolved != nil {
+		return o.Resolved
+	}
+	return o.Raw
+}
+
+var itemTypes = map[string]bool{
+	"AMMO":        true,
+	"ARMOR":       true,
+	"BIONIC_ITEM": true,
+	"BOOK":        true,
+	"COMESTIBLE":  true,
+	"CONTAINER":   true,
+	"ENGINE":      true,
+	"GENERIC":     true,
+	"GUN":         true,
+	"GUNMOD":      true,
+	"MAGAZINE":    true,
+	"PET_ARMOR":   true,
+	"TOOL":        true,
+	"TOOLMOD":     true,
+	"TOOL_ARMOR":  true,
+	"WHEEL":       true,
+}
+
+func readObjects(path, mod string) ([]object, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var data []map[string]interface{}
+	b = bytes.TrimSpace(b)
+	if bytes.HasPrefix(b, []byte("{")) {
+		var d map[string]interface{}
+		err = json.Unmarshal(b, &d)
+		data = append(data, d)
+	} else {
+		err = json.Unmarshal(b, &data)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+
+	objects := make([]object, 0, len(data))
+	for _, d := range data {
+		t := stringField(d, "type")
+		objects = append(objects, object{
+			ID:       objectID(t, d),
+			Abstract: stringField(d, "abstract"),
+			Type:     t,
+			Source:   path,
+			Mod:      mod,
+			Raw:      d,
+		})
+	}
+	return objects, nil
+}
+
+// objectID finds the identifier of an object. Most types use id, but a few
+// older or special cased types name themselves differently.
+func objectID(objectType string, d map[string]interface{}) string {
+	switch objectType {
+	case "recipe", "uncraft":
+		id := stringField(d, "result")
+		if suffix := stringField(d, "id_suffix"); suffix != "" {
+			id += "_" + suffix
+		}
+		return id
+	case "monstergroup", "MONSTER_FACTION":
+		return stringField(d, "name")
+	}
+	if id := stringField(d, "id"); id != "" {
+		return id
+	}
+	return stringField(d, "ident")
+}
+
+// objects reads every definition from the core data and the chosen mods, in
+// the order the game would load them.
+func (c *config) objects() ([]object, error) {
+	order, err := c.loadOrder()
+	if err != nil {
+		return nil, err
+	}
+	return objectsOf(order)
+}
+
+// objectsOf reads every object of the given mods in load order.
+func objectsOf(order []*mods.Mod) ([]object, error) {
+	for _, m := range order {
+		log.WithField("mod", m.ID).WithField("path", m.Path).Info("Using mod")
+	}
+
+	files, err := mods.Files(order)
+	if err != nil {
+		return nil, err
+	}
+
+	objects := []object{}
+	for _, f := range files {
+		if filepath.Base(f.Path) == "obsolete.json" {
+			continue
+		}
+		objs, err := readObjects(f.Path, f.Mod.ID)
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects, objs...)
+	}
+	return objects, nil
+}
+
+func stringField(decoded map[string]interface{}, key string) string {
+	if s, ok := decoded[key].(string); ok {
+		return s
+	}
+	return ""
+}
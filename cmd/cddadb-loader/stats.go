@@ -0,0 +1,38 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/ralreegorganon/cddadb"
+)
+
+func runStats(args []string) error {
+	var c config
+	fs := flag.NewFlagSet("stats", flag.ExitOnError)
+	c.registerDB(fs)
+	fs.StringVar(&c.version, "version", "", "dataset to count, defaults to the latest")
+	fs.Parse(args)
+
+	db, err := c.openDB()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	counts, err := (&cddadb.DB{DB: db}).GetTypes(c.version)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	total := 0
+	for _, c := range counts {
+		fmt.Fprintf(w, "%s\t%d\n", c.Type, c.Count)
+		total += c.Count
+	}
+	fmt.Fprintf(w, "total\t%d\n", total)
+	return w.Flush()
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"sort"
+
+	"github.com/ralreegorganon/cddadb/gettext"
+)
+
+// translation is the name and description of an object in one language.
+// NameForms are the plural forms of the name in the order the language's
+// Plural-Forms numbers them, Name being the one a single object takes.
+type translation struct {
+	Lang        string
+	Namespace   string
+	ID          string
+	Name        string
+	NameForms   []string
+	Description string
+}
+
+// translations looks up the names and descriptions of the objects in effect
+// once every mod has been loaded in the catalog of each language. Objects
+// with nothing translated are left out, so the API falls back to English.
+func translations(objects []object, catalogs map[string]*gettext.Catalog) []*translation {
+	effective := make(map[string]*object)
+	order := []string{}
+	for i := range objects {
+		o := &objects[i]
+		if o.ID == "" || o.Resolved == nil {
+			continue
+		}
+		k := o.namespace() + "/" + o.ID
+		if _, ok := effective[k]; !ok {
+			order = append(order, k)
+		}
+		effective[k] = o
+	}
+
+	langs := make([]string, 0, len(catalogs))
+	for lang := range catalogs {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+
+	ts := []*translation{}
+	for _, lang := range langs {
+		c := catalogs[lang]
+		for _, k := range order {
+			o := effective[k]
+			t := &translation{Lang: lang, Namespace: o.namespace(), ID: o.ID, NameForms: []string{}}
+			if ctxt, str, plural := name(o.Resolved); str != "" {
+				if forms := c.Forms(ctxt, str); len(forms) > 0 {
+					t.Name = c.NGettext(ctxt, str, plural, 1)
+					t.NameForms = forms
+				}
+			}
+			if d := stringField(o.Resolved, "description"); d != "" {
+				if forms := c.Forms("", d); len(forms) > 0 {
+					t.Description = forms[0]
+				}
+			}
+			if t.Name != "" || t.Description != "" {
+				ts = append(ts, t)
+			}
+		}
+	}
+	return ts
+}
+
+// name reads the English name of an object and its plural, which like
+// item names in the game defaults to the name with an s added.
+func name(resolved map[string]interface{}) (ctxt, str, plural string) {
+	switch n := resolved["name"].(type) {
+	case string:
+		str = n
+		plural = stringField(resolved, "name_plural")
+	case map[string]interface{}:
+		ctxt = stringField(n, "ctxt")
+		str = stringField(n, "str")
+		plural = stringField(n, "str_pl")
+	}
+	if plural == "" {
+		plural = str + "s"
+	}
+	return ctxt, str, plural
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/ralreegorganon/cddadb"
+)
+
+// The rules findings are grouped by, each with the level it is reported at
+// unless a finding says otherwise. Levels are SARIF's: an error is something
+// the game would reject or get wrong, a warning something it would ignore,
+// and a note something worth knowing that is probably intended.
+const (
+	ruleMissingType   = "missing-type"
+	ruleDuplicateID   = "duplicate-id"
+	ruleModOverride   = "mod-override"
+	ruleInheritance   = "inheritance"
+	ruleSchema        = "schema"
+	ruleReference     = "unknown-reference"
+	ruleUnknownMember = "unknown-member"
+)
+
+const (
+	levelError   = "error"
+	levelWarning = "warning"
+	levelNote    = "note"
+)
+
+var rules = []struct {
+	ID          string
+	Level       string
+	Description string
+}{
+	{ruleMissingType, levelError, "A definition has no type."},
+	{ruleDuplicateID, levelError, "An id is defined more than once by the same mod."},
+	{ruleModOverride, levelNote, "A mod replaces a definition from a mod loaded before it."},
+	{ruleInheritance, levelError, "A definition copies from a parent that isn't defined, or can't be resolved."},
+	{ruleSchema, levelError, "A definition doesn't decode into its typed form, or a quantity doesn't parse."},
+	{ruleReference, levelError, "A definition names an item, item group, requirement, ammo type or vehicle part that isn't defined."},
+	{ruleUnknownMember, levelWarning, "A definition has a member its type doesn't read, often a typo."},
+}
+
+// finding is a problem with one definition. Path is where in the definition,
+// as written, the problem is, like components[0][1][0], empty for the
+// definition as a whole.
+type finding struct {
+	Rule    string `json:"rule"`
+	Level   string `json:"level"`
+	Source  string `json:"source"`
+	ID      string `json:"id,omitempty"`
+	Path    string `json:"path,omitempty"`
+	Message string `json:"message"`
+}
+
+func (f finding) String() string {
+	s := f.Source
+	if f.ID != "" {
+		s += ": " + f.ID
+	}
+	if f.Path != "" {
+		s += ": " + f.Path
+	}
+	return fmt.Sprintf("%s: %s: %s", s, f.level(), f.Message)
+}
+
+func (f finding) level() string {
+	if f.Level != "" {
+		return f.Level
+	}
+	for _, r := range rules {
+		if r.ID == f.Rule {
+			return r.Level
+		}
+	}
+	return levelError
+}
+
+// withRule files findings that don't name a rule under one.
+func withRule(rule string, findings []finding) []finding {
+	for i := range findings {
+		if findings[i].Rule == "" {
+			findings[i].Rule = rule
+		}
+	}
+	return findings
+}
+
+func runValidate(args []string) error {
+	var c config
+	var format string
+	fs := flag.NewFlagSet("validate", flag.ExitOnError)
+	c.registerData(fs)
+	fs.StringVar(&format, "format", "text", "report format, text, json or sarif")
+	fs.Parse(args)
+
+	var write func(findings []finding) error
+	switch format {
+	case "text":
+		write = func(findings []finding) error {
+			for _, f := range findings {
+				fmt.Fprintln(os.Stdout, f)
+			}
+			return nil
+		}
+	case "json":
+		write = func(findings []finding) error {
+			return writeReport(jsonReport(findings))
+		}
+	case "sarif":
+		write = func(findings []finding) error {
+			return writeReport(sarifReport(findings, c.gameRoot))
+		}
+	default:
+		return fmt.Errorf("unknown report format %s, use text, json or sarif", format)
+	}
+
+	objects, err := c.objects()
+	if err != nil {
+		return err
+	}
+
+	findings := validate(objects)
+	findings = append(findings, withRule(ruleInheritance, resolve(objects))...)
+	findings = append(findings, validateItems(objects)...)
+	findings = append(findings, validateReferences(objects)...)
+	findings = append(findings, validateVehicles(objects)...)
+	findings = append(findings, validateMembers(objects)...)
+	for i := range findings {
+		findings[i].Level = findings[i].level()
+	}
+	if err := write(findings); err != nil {
+		return err
+	}
+
+	errors := 0
+	for _, f := range findings {
+		if f.Level == levelError {
+			errors++
+		}
+	}
+	if errors > 0 {
+		return fmt.Errorf("validation failed with %d errors", errors)
+	}
+	return nil
+}
+
+func writeReport(report interface{}) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(report)
+}
+
+// validate checks that every definition has a type and that no mod defines
+// an id twice. A mod replacing what an earlier mod defined is how mods work,
+// so that is only noted.
+func validate(objects []object) []finding {
+	findings := []finding{}
+	seen := make(map[string]*object)
+
+	for i := range objects {
+		o := &objects[i]
+		if o.Type == "" {
+			findings = append(findings, finding{Rule: ruleMissingType, Source: o.Source, ID: o.key(), Path: "type", Message: "no type"})
+			continue
+		}
+		key := o.key()
+		if key == "" {
+			continue
+		}
+		qualified := o.namespace() + "/" + key
+		if first, ok := seen[qualified]; ok {
+			if first.Mod == o.Mod {
+				findings = append(findings, finding{Rule: ruleDuplicateID, Source: o.Source, ID: key, Message: "duplicate of definition in " + first.Source})
+			} else {
+				findings = append(findings, finding{Rule: ruleModOverride, Source: o.Source, ID: key, Message: fmt.Sprintf("replaces definition from %s in %s", first.Mod, first.Source)})
+			}
+		}
+		seen[qualified] = o
+	}
+
+	return findings
+}
+
+// validateItems checks that every resolved item decodes into its typed form
+// and that its quantities parse.
+func validateItems(objects []object) []finding {
+	findings := []finding{}
+	for _, o := range objects {
+		if o.namespace() != "item" || o.Resolved == nil {
+			continue
+		}
+		b, err := json.Marshal(o.Resolved)
+		if err != nil {
+			findings = append(findings, finding{Rule: ruleSchema, Source: o.Source, ID: o.key(), Message: err.Error()})
+			continue
+		}
+		if _, err := cddadb.DecodeItemType(b); err != nil {
+			findings = append(findings, finding{Rule: ruleSchema, Source: o.Source, ID: o.key(), Message: err.Error()})
+		}
+		if _, err := measure(&o); err != nil {
+			findings = append(findings, finding{Rule: ruleSchema, Source: o.Source, ID: o.key(), Message: err.Error()})
+		}
+	}
+	return findings
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+)
+
+// vehiclePartInstalls reads what it takes to install each vehicle part in
+// effect once all mods have loaded, as recipes of type vehicle_part_install
+// keyed by the part and resulting in the part's item.
+func vehiclePartInstalls(objects []object) ([]*recipe, []finding) {
+	requirements := requirementTable(objects)
+	effective := make(map[string]*object)
+	order := []string{}
+	for i := range objects {
+		o := &objects[i]
+		if o.Type != "vehicle_part" || o.ID == "" || o.Resolved == nil {
+			continue
+		}
+		if _, ok := effective[o.ID]; !ok {
+			order = append(order, o.ID)
+		}
+		effective[o.ID] = o
+	}
+
+	findings := []finding{}
+	rs := []*recipe{}
+	for _, id := range order {
+		o := effective[id]
+		install := map[string]interface{}{}
+		if reqs, ok := o.Resolved["requirements"].(map[string]interface{}); ok {
+			if i, ok := reqs["install"].(map[string]interface{}); ok {
+				install = i
+			}
+		}
+
+		e := &expander{requirements: requirements}
+		r := &recipe{
+			Type:   "vehicle_part_install",
+			ID:     id,
+			Result: stringField(o.Resolved, "item"),
+			Skills: skills(install["skills"]),
+		}
+		// Older parts only give the mechanics level needed.
+		if len(r.Skills) == 0 {
+			if d := intField(o.Resolved, "difficulty"); d > 0 {
+				r.Skills = []requirementSkill{{ID: "mechanics", Level: d}}
+			}
+		}
+		if t, ok := install["time"]; ok {
+			moves, err := recipeTime(t)
+			if err != nil {
+				e.errs = append(e.errs, fmt.Errorf("install time: %v", err))
+			} else {
+				r.Time = &moves
+			}
+		}
+		e.add(r, install, "", 1, 0)
+		for _, err := range e.errs {
+			findings = append(findings, finding{Source: o.Source, ID: id, Message: err.Error()})
+		}
+		rs = append(rs, r)
+	}
+	return rs, findings
+}
+
+// validateVehicles checks that every part a prebuilt vehicle mounts is
+// defined.
+func validateVehicles(objects []object) []finding {
+	parts := make(map[string]bool)
+	for _, o := range objects {
+		if o.Type == "vehicle_part" && o.ID != "" {
+			parts[o.ID] = true
+		}
+	}
+
+	findings := []finding{}
+	for _, o := range objects {
+		if o.Type != "vehicle" || o.Resolved == nil {
+			continue
+		}
+		for i, t := range list(o.Resolved["parts"]) {
+			tile, ok := t.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			base := fmt.Sprintf("parts[%d]", i)
+			paths := make(map[string]string)
+			ids := []string{}
+			if id := stringField(tile, "part"); id != "" {
+				ids = append(ids, id)
+				paths[id] = base + ".part"
+			}
+			for j, p := range list(tile["parts"]) {
+				var id, path string
+				switch p := p.(type) {
+				case string:
+					id, path = p, fmt.Sprintf("%s.parts[%d]", base, j)
+				case map[string]interface{}:
+					id, path = stringField(p, "part"), fmt.Sprintf("%s.parts[%d].part", base, j)
+				}
+				ids = append(ids, id)
+				if _, ok := paths[id]; !ok {
+					paths[id] = path
+				}
+			}
+			for _, id := range ids {
+				if !parts[id] {
+					findings = append(findings, finding{Rule: ruleReference, Source: o.Source, ID: o.ID, Path: paths[id], Message: fmt.Sprintf("unknown part %s at %v,%v", id, tile["x"], tile["y"])})
+				}
+			}
+		}
+	}
+	return findings
+}
@@ -2,10 +2,13 @@ package main
 
 import (
 	"flag"
+	"os"
+	"path/filepath"
 
 	"github.com/ralreegorganon/cddadb/cmd/cddadb-map/metadata"
 	"github.com/ralreegorganon/cddadb/cmd/cddadb-map/overmap"
 	"github.com/ralreegorganon/cddadb/cmd/cddadb-map/rasterize"
+	"github.com/ralreegorganon/cddadb/mods"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -17,19 +20,29 @@ func init() {
 }
 
 func main() {
+	gameRoot := flag.String("root", os.Getenv("CDDADB_GAME_ROOT"), "path to a Cataclysm-DDA checkout or install (env CDDADB_GAME_ROOT)")
+	save := flag.String("save", os.Getenv("CDDADB_SAVE"), "save directory of the world to render (env CDDADB_SAVE)")
+	out := flag.String("out", os.Getenv("CDDADB_MAP_OUT"), "directory to write the rendered map to (env CDDADB_MAP_OUT)")
 	flag.Parse()
 
-	jsonRoot := "/Users/jj/code/Cataclysm-DDA/data/json"
-	modsRoot := "/Users/jj/code/Cataclysm-DDA/data/mods"
+	if *gameRoot == "" || *save == "" || *out == "" {
+		log.Fatal("a game root, save and output directory are needed, use -root, -save and -out")
+	}
+	jsonRoot := filepath.Join(*gameRoot, "data", "json")
+	modsRoot := filepath.Join(*gameRoot, "data", "mods")
+
+	active, err := mods.FromSave(*save)
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	m := metadata.NewOvermap()
-	err := m.BuildUp(jsonRoot, modsRoot)
+	err = m.BuildUp(jsonRoot, modsRoot, active)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	save := "/Users/jj/code/Cataclysm-DDA/save/Hannastown"
-	// save := "/Users/jj/Downloads/trinitycenter"
-	o, err := overmap.FromSave(save)
+	o, err := overmap.FromSave(*save)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -39,13 +52,12 @@ func main() {
 		log.Fatal(err)
 	}
 
-	// err = w.RenderToFiles("/Users/jj/Desktop/Hannastown")
+	// err = w.RenderToFiles(*out)
 	// if err != nil {
 	// 	log.Fatal(err)
 	// }
 
-	// err = rasterize.Blam2("/Users/jj/Desktop/TrinityCenter/", w)
-	err = rasterize.Blam2("/Users/jj/Desktop/Hannastown", w)
+	err = rasterize.Blam2(*out, w)
 	if err != nil {
 		log.Fatal(err)
 	}
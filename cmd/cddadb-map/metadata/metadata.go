@@ -9,17 +9,16 @@ import (
 	"io"
 	"io/ioutil"
 	"os"
-	"path/filepath"
 	"sort"
-	"strings"
 
 	"github.com/davecgh/go-spew/spew"
-	"github.com/imdario/mergo"
+	"github.com/ralreegorganon/cddadb/inherit"
+	"github.com/ralreegorganon/cddadb/mods"
 	log "github.com/sirupsen/logrus"
 )
 
 type Overmap struct {
-	templates map[string]overmapTerrain
+	templates []*inherit.Definition
 	built     map[string]overmapTerrain
 	symbols   map[int]string
 	rotations [][]int
@@ -54,26 +53,6 @@ type mapGen struct {
 
 const overmapTerrainTypeID = "overmap_terrain"
 
-type inLoadOrder []string
-
-func (s inLoadOrder) Len() int {
-	return len(s)
-}
-
-func (s inLoadOrder) Swap(i, j int) {
-	s[i], s[j] = s[j], s[i]
-}
-
-func (s inLoadOrder) Less(i, j int) bool {
-	c1 := strings.Count(s[i], "/")
-	c2 := strings.Count(s[j], "/")
-
-	if c1 == c2 {
-		return s[i] < s[j]
-	}
-	return c1 < c2
-}
-
 func indexOf(slice []int, item int) int {
 	for i := range slice {
 		if slice[i] == item {
@@ -142,7 +121,7 @@ func NewOvermap() *Overmap {
 	}
 
 	for i := 0; i < 128; i++ {
-		symbols[i] = string(i)
+		symbols[i] = string(rune(i))
 	}
 
 	rotations := make([][]int, 0)
@@ -152,7 +131,7 @@ func NewOvermap() *Overmap {
 	rotations = append(rotations, []int{4194420, 4194423, 4194421, 4194422})
 
 	l := &Overmap{
-		templates: make(map[string]overmapTerrain),
+		templates: make([]*inherit.Definition, 0),
 		built:     make(map[string]overmapTerrain),
 		symbols:   symbols,
 		rotations: rotations,
@@ -160,8 +139,10 @@ func NewOvermap() *Overmap {
 	return l
 }
 
-func (o *Overmap) BuildUp(jsonRoot, modsRoot string) error {
-	files, err := sourceFiles(jsonRoot, modsRoot)
+// BuildUp loads the overmap terrain of the core data and of the active
+// mods, found below modsRoot, in the order the game would load them.
+func (o *Overmap) BuildUp(jsonRoot, modsRoot string, active []string) error {
+	files, err := sourceFiles(jsonRoot, modsRoot, active)
 	if err != nil {
 		return err
 	}
@@ -179,43 +160,26 @@ func (o *Overmap) BuildUp(jsonRoot, modsRoot string) error {
 	return nil
 }
 
-func sourceFiles(jsonRoot, modsRoot string) ([]string, error) {
-	files := []string{}
+func sourceFiles(jsonRoot, modsRoot string, active []string) ([]string, error) {
+	available, err := mods.Discover(jsonRoot, modsRoot)
+	if err != nil {
+		return nil, err
+	}
 
-	err := filepath.Walk(jsonRoot, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-		if info.IsDir() {
-			return nil
-		}
-		if strings.HasSuffix(path, ".json") {
-			files = append(files, path)
-		}
-		return nil
-	})
+	order, err := mods.LoadOrder(available, active)
 	if err != nil {
 		return nil, err
 	}
 
-	// need to filter to active mods for save
-	// err = filepath.Walk(modsRoot, func(path string, info os.FileInfo, err error) error {
-	// 	if err != nil {
-	// 		return err
-	// 	}
-	// 	if info.IsDir() {
-	// 		return nil
-	// 	}
-	// 	if strings.HasSuffix(path, ".json") {
-	// 		files = append(files, path)
-	// 	}
-	// 	return nil
-	// })
-	// if err != nil {
-	// 	return nil, err
-	// }
-
-	sort.Sort(inLoadOrder(files))
+	found, err := mods.Files(order)
+	if err != nil {
+		return nil, err
+	}
+
+	files := []string{}
+	for _, f := range found {
+		files = append(files, f.Path)
+	}
 
 	return files, nil
 }
@@ -236,33 +200,19 @@ func (o *Overmap) loadTemplates(r io.Reader) error {
 		return err
 	}
 
-	filteredOvermapTerrains := make([]map[string]interface{}, 0)
 	for _, t := range temp {
-		if t["type"].(string) == overmapTerrainTypeID {
-			filteredOvermapTerrains = append(filteredOvermapTerrains, t)
-		}
-	}
-
-	filteredText, err := json.Marshal(filteredOvermapTerrains)
-	if err != nil {
-		return err
-	}
-
-	var overmapTerrains []overmapTerrain
-	err = json.Unmarshal(filteredText, &overmapTerrains)
-	if err != nil {
-		return err
-	}
-
-	for _, ot := range overmapTerrains {
-		if ot.Type != overmapTerrainTypeID {
+		if t["type"] != overmapTerrainTypeID {
 			continue
 		}
-		if ot.Abstract != "" {
-			o.templates[ot.Abstract] = ot
-		} else {
-			o.templates[ot.ID] = ot
+		key, _ := t["abstract"].(string)
+		if key == "" {
+			key, _ = t["id"].(string)
 		}
+		o.templates = append(o.templates, &inherit.Definition{
+			Namespace: overmapTerrainTypeID,
+			Key:       key,
+			Raw:       t,
+		})
 	}
 
 	return nil
@@ -283,64 +233,58 @@ func (o *Overmap) loadTemplatesFromFiles(files []string) error {
 }
 
 func (o *Overmap) buildTemplates() error {
-	for _, ot := range o.templates {
-		bt := make([]overmapTerrain, 0)
-		t := ot
-		bt = append(bt, t)
-		for t.CopyFrom != "" {
-			t = o.templates[t.CopyFrom]
-			bt = append(bt, t)
-		}
+	r := &inherit.Resolver{}
+	r.Resolve(o.templates)
 
-		b := overmapTerrain{}
-		for i := len(bt) - 1; i >= 0; i-- {
-			if err := mergo.Merge(&b, bt[i], mergo.WithOverride); err != nil {
-				return err
-			}
+	for _, d := range o.templates {
+		if d.Err != nil {
+			log.WithField("id", d.Key).Warn(d.Err)
+			continue
+		}
+		if _, abstract := d.Raw["abstract"]; abstract {
+			continue
 		}
 
-		if ot.Abstract == "" {
-			b.Abstract = ""
-			b.CopyFrom = ""
-			o.built[b.ID] = b
-
-			rotate := true
-
-			if b.Flags != nil {
-				for _, f := range b.Flags {
-					if f == "NO_ROTATE" {
-						rotate = false
-					} else if f == "LINEAR" {
-						for _, suffix := range linearSuffixes {
-							bs := overmapTerrain{}
-							if err := mergo.Merge(&bs, b, mergo.WithOverride); err != nil {
-								return err
-							}
-							bs.ID = b.ID + suffix
-							bs.Sym = linearSuffixSymbols[suffix]
-							o.built[bs.ID] = bs
-						}
+		text, err := json.Marshal(d.Resolved)
+		if err != nil {
+			return err
+		}
+		var b overmapTerrain
+		if err := json.Unmarshal(text, &b); err != nil {
+			return err
+		}
+		o.built[b.ID] = b
+
+		rotate := true
+
+		if b.Flags != nil {
+			for _, f := range b.Flags {
+				if f == "NO_ROTATE" {
+					rotate = false
+				} else if f == "LINEAR" {
+					for _, suffix := range linearSuffixes {
+						bs := b
+						bs.ID = b.ID + suffix
+						bs.Sym = linearSuffixSymbols[suffix]
+						o.built[bs.ID] = bs
 					}
 				}
 			}
+		}
 
-			if rotate {
-				for i, suffix := range rotationSuffixes {
-					bs := overmapTerrain{}
-					if err := mergo.Merge(&bs, b, mergo.WithOverride); err != nil {
-						log.Fatal(err)
-					}
-					bs.ID = b.ID + suffix
-
-					for _, r := range o.rotations {
-						index := indexOf(r, b.Sym)
-						if index != -1 {
-							bs.Sym = r[(i+index+4)%4]
-							break
-						}
+		if rotate {
+			for i, suffix := range rotationSuffixes {
+				bs := b
+				bs.ID = b.ID + suffix
+
+				for _, r := range o.rotations {
+					index := indexOf(r, b.Sym)
+					if index != -1 {
+						bs.Sym = r[(i+index+4)%4]
+						break
 					}
-					o.built[bs.ID] = bs
 				}
+				o.built[bs.ID] = bs
 			}
 		}
 	}
@@ -0,0 +1,422 @@
+package cddadb
+
+import (
+	"math"
+	"sort"
+)
+
+// maxCraftDepth stops a plan from following recipes forever when the data is
+// deeper than anything sensible.
+const maxCraftDepth = 32
+
+// missingToolCost is what a recipe costs, in items to go and find, for each
+// tool, quality or skill it needs and the inventory doesn't have.
+const missingToolCost = 10
+
+// CraftRequest is what is posted to plan crafting an item: how many to make
+// and what the crafter has on hand. A tool's count in Items is read as the
+// charges it has.
+type CraftRequest struct {
+	Count     int            `json:"count"`
+	Items     map[string]int `json:"items"`
+	Qualities map[string]int `json:"qualities"`
+	Skills    map[string]int `json:"skills"`
+}
+
+// CraftPlan is the tree of crafts that makes the target, along with
+// everything missing from the inventory to carry it out.
+type CraftPlan struct {
+	Target    string        `json:"target"`
+	Count     int           `json:"count"`
+	Craftable bool          `json:"craftable"`
+	Tree      *CraftNode    `json:"tree"`
+	Missing   *CraftMissing `json:"missing"`
+}
+
+// CraftNode is one item in the tree. FromInventory of Count come out of the
+// inventory, the rest are made with Recipe or, when that isn't possible, are
+// Missing. Batches is how many times Recipe is made, each making the recipe's
+// Makes. Cycle marks an item whose recipe would need the item itself.
+type CraftNode struct {
+	Item          string        `json:"item"`
+	Count         int           `json:"count"`
+	FromInventory int           `json:"from_inventory"`
+	Recipe        string        `json:"recipe,omitempty"`
+	Batches       int           `json:"batches,omitempty"`
+	Missing       int           `json:"missing,omitempty"`
+	Cycle         bool          `json:"cycle,omitempty"`
+	Tools         []*CraftCheck `json:"tools,omitempty"`
+	Qualities     []*CraftCheck `json:"qualities,omitempty"`
+	Skills        []*CraftCheck `json:"skills,omitempty"`
+	Components    []*CraftNode  `json:"components,omitempty"`
+}
+
+// CraftCheck is a tool, quality or skill a recipe needs, Amount being the
+// charges, level or skill level, and whether the inventory covers it.
+type CraftCheck struct {
+	ID     string `json:"id"`
+	Amount int    `json:"amount"`
+	Met    bool   `json:"met"`
+}
+
+// CraftMissing adds up what the plan lacks.
+type CraftMissing struct {
+	Items     map[string]int `json:"items"`
+	Tools     map[string]int `json:"tools"`
+	Qualities map[string]int `json:"qualities"`
+	Skills    map[string]int `json:"skills"`
+}
+
+type planner struct {
+	recipes   map[string][]*Recipe
+	items     map[string]int
+	qualities map[string]int
+	skills    map[string]int
+	costs     map[string]float64
+	missing   *CraftMissing
+}
+
+// PlanCraft works out how to make count of target from the inventory, with
+// recipes keyed by their result.
+//
+// Every component group is met with its cheapest alternative, the cost of an
+// item being how many base materials, ones with no recipe, would have to be
+// found beyond the inventory to make it. Recipes whose tools, qualities or
+// skills are missing cost more so one that can be made now is preferred.
+//
+// The material cost of every item is worked out once for the plan, from the
+// recipes alone, so it doesn't matter how the tree is walked.
+func PlanCraft(target string, recipes map[string][]*Recipe, req *CraftRequest) *CraftPlan {
+	count := req.Count
+	if count < 1 {
+		count = 1
+	}
+
+	p := &planner{
+		recipes:   recipes,
+		items:     copyCounts(req.Items),
+		qualities: copyCounts(req.Qualities),
+		skills:    copyCounts(req.Skills),
+		costs:     materialCosts(recipes),
+		missing: &CraftMissing{
+			Items:     make(map[string]int),
+			Tools:     make(map[string]int),
+			Qualities: make(map[string]int),
+			Skills:    make(map[string]int),
+		},
+	}
+
+	// The target has to be made, not taken from the inventory.
+	tree := &CraftNode{Item: target, Count: count}
+	p.craft(tree, map[string]bool{}, 0)
+	p.summarize(tree)
+
+	m := p.missing
+	return &CraftPlan{
+		Target:    target,
+		Count:     count,
+		Craftable: len(m.Items)+len(m.Tools)+len(m.Qualities)+len(m.Skills) == 0,
+		Tree:      tree,
+		Missing:   m,
+	}
+}
+
+func copyCounts(m map[string]int) map[string]int {
+	c := make(map[string]int, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
+
+// build fills in a node for count of item, taking what it can from the
+// inventory and crafting the rest.
+func (p *planner) build(item string, count int, path map[string]bool, depth int) *CraftNode {
+	n := &CraftNode{Item: item, Count: count}
+
+	if have := p.items[item]; have > 0 {
+		n.FromInventory = minInt(have, count)
+		p.items[item] -= n.FromInventory
+	}
+	if n.FromInventory == count {
+		return n
+	}
+
+	p.craft(n, path, depth)
+	return n
+}
+
+// craft makes whatever of the node didn't come from the inventory.
+func (p *planner) craft(n *CraftNode, path map[string]bool, depth int) {
+	need := n.Count - n.FromInventory
+
+	if path[n.Item] {
+		n.Cycle = true
+		n.Missing = need
+		p.missing.Items[n.Item] += need
+		return
+	}
+
+	r, cost := p.bestRecipe(n.Item)
+	if r == nil || math.IsInf(cost, 1) || depth >= maxCraftDepth {
+		// Recipes that can only be made from the item itself are as good as
+		// none at all.
+		n.Cycle = r != nil && math.IsInf(cost, 1)
+		n.Missing = need
+		p.missing.Items[n.Item] += need
+		return
+	}
+
+	path[n.Item] = true
+	defer delete(path, n.Item)
+
+	batches := batchesFor(r, need)
+	n.Recipe = r.ID
+	n.Batches = batches
+	n.Tools = p.checkTools(r, batches)
+	n.Qualities = p.checkQualities(r)
+	n.Skills = p.checkSkills(r)
+
+	for _, group := range r.Components {
+		alt := p.cheapest(group, batches, path)
+		if alt == nil {
+			continue
+		}
+		n.Components = append(n.Components, p.build(alt.ID, alt.Count*batches, path, depth+1))
+	}
+}
+
+// batchesFor is how many times a recipe has to be made for need of its
+// result, the last batch making more than needed when need doesn't divide.
+func batchesFor(r *Recipe, need int) int {
+	makes := r.Makes
+	if makes < 1 {
+		makes = 1
+	}
+	return (need + makes - 1) / makes
+}
+
+// cheapest picks the alternative of a component group that leaves the least
+// to find, counting what the inventory already has. Making an item already
+// being made further up the tree would go round in a circle, so those are
+// only picked when nothing else will do.
+func (p *planner) cheapest(group []*RecipeItem, batches int, path map[string]bool) *RecipeItem {
+	var best *RecipeItem
+	bestCost := math.Inf(1)
+	for _, alt := range group {
+		need := alt.Count*batches - p.items[alt.ID]
+		cost := 0.0
+		if need > 0 {
+			cost = float64(need) * itemCost(alt.ID, p.costs)
+			if path[alt.ID] {
+				cost = math.Inf(1)
+			}
+		}
+		if best == nil || cost < bestCost {
+			best = alt
+			bestCost = cost
+		}
+	}
+	return best
+}
+
+// bestRecipe is the cheapest way to make an item and what making one with it
+// costs, nil for a base material. A recipe needing the item itself is no way
+// to make it, and one the inventory lacks the tools, qualities or skills for
+// costs more.
+func (p *planner) bestRecipe(item string) (*Recipe, float64) {
+	var best *Recipe
+	bestCost := math.Inf(1)
+	for _, r := range p.recipes[item] {
+		c := recipeMaterials(r, p.costs, item)/float64(maxInt(r.Makes, 1)) + p.missingCost(r)
+		if best == nil || c < bestCost {
+			best = r
+			bestCost = c
+		}
+	}
+	return best, bestCost
+}
+
+// missingCost is what the tools, qualities and skills of a recipe that the
+// inventory doesn't cover add to its cost.
+func (p *planner) missingCost(r *Recipe) float64 {
+	total := 0.0
+	for _, t := range p.checkTools(r, 1) {
+		if !t.Met {
+			total += missingToolCost
+		}
+	}
+	for _, q := range p.checkQualities(r) {
+		if !q.Met {
+			total += missingToolCost
+		}
+	}
+	for _, s := range p.checkSkills(r) {
+		if !s.Met {
+			total += missingToolCost
+		}
+	}
+	return total
+}
+
+// materialCosts works out how many base materials making one of each
+// craftable item takes, leaving the inventory and tools out of it.
+//
+// Every cost starts out infinite and each step lowers it to the cheapest
+// recipe made from the costs of the step before, which is the cheapest way
+// to make the item in that many steps of the recipe graph. The costs settle
+// after at most maxCraftDepth steps however many cycles the recipes have,
+// and an item only reachable through a cycle stays infinite.
+func materialCosts(recipes map[string][]*Recipe) map[string]float64 {
+	costs := make(map[string]float64, len(recipes))
+	for item := range recipes {
+		costs[item] = math.Inf(1)
+	}
+	for step := 0; step < maxCraftDepth; step++ {
+		next := make(map[string]float64, len(costs))
+		changed := false
+		for item, rs := range recipes {
+			best := costs[item]
+			for _, r := range rs {
+				best = math.Min(best, recipeMaterials(r, costs, "")/float64(maxInt(r.Makes, 1)))
+			}
+			next[item] = best
+			changed = changed || best != costs[item]
+		}
+		costs = next
+		if !changed {
+			break
+		}
+	}
+	return costs
+}
+
+// recipeMaterials is the material cost of one batch of a recipe, counting
+// any alternative that is exclude as impossible.
+func recipeMaterials(r *Recipe, costs map[string]float64, exclude string) float64 {
+	total := 0.0
+	for _, group := range r.Components {
+		best := math.Inf(1)
+		for _, alt := range group {
+			if alt.ID == exclude {
+				continue
+			}
+			best = math.Min(best, float64(alt.Count)*itemCost(alt.ID, costs))
+		}
+		total += best
+	}
+	return total
+}
+
+// itemCost is the material cost of one of item, 1 for a base material.
+func itemCost(item string, costs map[string]float64) float64 {
+	if c, ok := costs[item]; ok {
+		return c
+	}
+	return 1
+}
+
+// checkTools picks a tool from each group, the first the inventory can cover
+// or else the first listed, and records the ones it can't.
+func (p *planner) checkTools(r *Recipe, batches int) []*CraftCheck {
+	checks := []*CraftCheck{}
+	for _, group := range r.Tools {
+		var pick *CraftCheck
+		for _, t := range group {
+			charges := t.Count
+			if charges > 0 {
+				charges *= batches
+			}
+			c := &CraftCheck{ID: t.ID, Amount: charges}
+			have := p.items[t.ID]
+			c.Met = have > 0 && (charges <= 0 || have >= charges)
+			if c.Met {
+				pick = c
+				break
+			}
+			if pick == nil {
+				pick = c
+			}
+		}
+		if pick != nil {
+			checks = append(checks, pick)
+		}
+	}
+	return checks
+}
+
+func (p *planner) checkQualities(r *Recipe) []*CraftCheck {
+	checks := []*CraftCheck{}
+	for _, group := range r.Qualities {
+		var pick *CraftCheck
+		for _, q := range group {
+			c := &CraftCheck{ID: q.ID, Amount: q.Level, Met: p.qualities[q.ID] >= q.Level}
+			if c.Met {
+				pick = c
+				break
+			}
+			if pick == nil {
+				pick = c
+			}
+		}
+		if pick != nil {
+			checks = append(checks, pick)
+		}
+	}
+	return checks
+}
+
+// checkSkills lists the primary skill at the recipe's difficulty followed by
+// any other skills it requires.
+func (p *planner) checkSkills(r *Recipe) []*CraftCheck {
+	checks := []*CraftCheck{}
+	if r.SkillUsed != "" {
+		checks = append(checks, &CraftCheck{ID: r.SkillUsed, Amount: r.Difficulty, Met: p.skills[r.SkillUsed] >= r.Difficulty})
+	}
+	for _, s := range r.Skills {
+		checks = append(checks, &CraftCheck{ID: s.ID, Amount: s.Level, Met: p.skills[s.ID] >= s.Level})
+	}
+	return checks
+}
+
+// summarize adds the unmet tools, qualities and skills of the tree to the
+// missing totals, keeping the most asked for.
+func (p *planner) summarize(n *CraftNode) {
+	unmet(p.missing.Tools, n.Tools)
+	unmet(p.missing.Qualities, n.Qualities)
+	unmet(p.missing.Skills, n.Skills)
+	for _, c := range n.Components {
+		p.summarize(c)
+	}
+}
+
+func unmet(totals map[string]int, checks []*CraftCheck) {
+	for _, c := range checks {
+		if c.Met {
+			continue
+		}
+		if cur, ok := totals[c.ID]; !ok || c.Amount > cur {
+			totals[c.ID] = c.Amount
+		}
+	}
+}
+
+// recipesByResult indexes recipes by the item they make, in id order so
+// ties between equally cheap recipes always go the same way.
+func recipesByResult(recipes []*Recipe) map[string][]*Recipe {
+	m := make(map[string][]*Recipe)
+	for _, r := range recipes {
+		m[r.Result] = append(m[r.Result], r)
+	}
+	for _, rs := range m {
+		sort.Slice(rs, func(i, j int) bool { return rs[i].ID < rs[j].ID })
+	}
+	return m
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,17 @@
+package cddadb
+
+import (
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Dataset is one load of the game data, named for the release or commit it
+// was loaded from.
+type Dataset struct {
+	ID       int            `json:"-" db:"dataset_id"`
+	Version  string         `json:"version" db:"version"`
+	Commit   string         `json:"commit" db:"commit"`
+	LoadedAt time.Time      `json:"loaded_at" db:"loaded_at"`
+	Mods     pq.StringArray `json:"mods" db:"mods"`
+}
@@ -1,11 +1,24 @@
 package cddadb
 
 import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	log "github.com/sirupsen/logrus"
 )
 
 type DB struct {
 	*sqlx.DB
+
+	// groups caches the parsed item groups of each dataset, which never
+	// change once loaded.
+	groupsMu sync.Mutex
+	groups   map[int]map[string]*ItemGroup
 }
 
 func (db *DB) Open(connectionString string) error {
@@ -17,18 +30,1963 @@ func (db *DB) Open(connectionString string) error {
 	return nil
 }
 
-func (db *DB) GetItems() ([]*Item, error) {
-	items := []*Item{}
-	err := db.Select(&items, `
-		select 
-			id, 
-			abstract,
+func (db *DB) GetDatasets() ([]*Dataset, error) {
+	datasets := []*Dataset{}
+	err := db.Select(&datasets, `
+		select
+			dataset_id,
+			version,
+			coalesce(commit, '') as commit,
+			loaded_at,
+			mods
+		from
+			dataset
+		order by
+			loaded_at desc
+	`)
+	if err != nil {
+		return nil, err
+	}
+	return datasets, nil
+}
+
+// datasetID finds the dataset loaded as version, or the most recently loaded
+// one when no version is given.
+func (db *DB) datasetID(version string) (int, error) {
+	var id int
+	var err error
+	if version == "" {
+		err = db.Get(&id, `
+			select
+				dataset_id
+			from
+				dataset
+			order by
+				loaded_at desc
+			limit 1
+		`)
+	} else {
+		err = db.Get(&id, `
+			select
+				dataset_id
+			from
+				dataset
+			where
+				version = $1
+		`, version)
+	}
+	if err == sql.ErrNoRows {
+		if version == "" {
+			return 0, &NotFoundError{What: "loaded game data"}
+		}
+		return 0, &NotFoundError{What: "version " + version}
+	}
+	return id, err
+}
+
+// GetItems returns a page of the items matching the query, along with the
+// cursor for the next page or nil when this is the last.
+func (db *DB) GetItems(q *ItemQuery) ([]*Item, *ItemCursor, error) {
+	dataset, err := db.datasetID(q.Version)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	conds, args := q.where([]interface{}{dataset})
+	where := "dataset_id = $1"
+	for _, c := range conds {
+		where += "\n\t\t\tand " + c
+	}
+
+	rows := []struct {
+		Item
+		Row       int64       `db:"game_object_id"`
+		SortValue interface{} `db:"sort_value"`
+	}{}
+	err = db.Select(&rows, fmt.Sprintf(`
+		select
+			game_object_id,
+			%s as sort_value,
+			coalesce(id, '') as id,
+			coalesce(abstract, '') as abstract,
+			type,
+			mod,
+			coalesce(resolved#>>'{name,str}', resolved->>'name', '') as name,
+			weight,
+			volume,
+			spoils_in
+		from
+			item
+		where
+			%s
+		order by
+			%s
+		limit %d
+	`, itemSorts[q.Sort], where, q.orderBy(), q.Limit+1), args...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var next *ItemCursor
+	if len(rows) > q.Limit {
+		rows = rows[:q.Limit]
+		last := rows[len(rows)-1]
+		v := last.SortValue
+		if b, ok := v.([]byte); ok {
+			v = string(b)
+		}
+		next = &ItemCursor{Value: v, Row: last.Row}
+	}
+
+	items := make([]*Item, len(rows))
+	ids := make([]string, 0, len(rows))
+	for i := range rows {
+		items[i] = &rows[i].Item
+		if items[i].ID != "" {
+			ids = append(ids, items[i].ID)
+		}
+	}
+
+	names, err := db.translatedNames(dataset, q.Lang, "item", ids)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, i := range items {
+		if n, ok := names[i.ID]; ok {
+			i.Name = n
+		}
+	}
+	return items, next, nil
+}
+
+func (db *DB) GetTypes(version string) ([]*TypeCount, error) {
+	dataset, err := db.datasetID(version)
+	if err != nil {
+		return nil, err
+	}
+
+	types := []*TypeCount{}
+	err = db.Select(&types, `
+		select
+			type,
+			count(*) as count
+		from
+			game_object
+		where
+			dataset_id = $1
+		group by
 			type
-		from 
+		order by
+			type
+	`, dataset)
+	if err != nil {
+		return nil, err
+	}
+	return types, nil
+}
+
+func (db *DB) GetObjects(version, objectType string) ([]*GameObject, error) {
+	dataset, err := db.datasetID(version)
+	if err != nil {
+		return nil, err
+	}
+
+	objects := []*GameObject{}
+	err = db.Select(&objects, `
+		select
+			coalesce(id, '') as id,
+			coalesce(abstract, '') as abstract,
+			type,
+			source,
+			mod,
+			raw,
+			resolved
+		from
+			game_object
+		where
+			dataset_id = $1
+			and type = $2
+		order by
+			game_object_id
+	`, dataset, objectType)
+	if err != nil {
+		return nil, err
+	}
+	return objects, nil
+}
+
+func (db *DB) GetItemType(version, id string) (*ItemType, error) {
+	dataset, err := db.datasetID(version)
+	if err != nil {
+		return nil, err
+	}
+	return db.itemType(dataset, id)
+}
+
+func (db *DB) itemType(dataset int, id string) (*ItemType, error) {
+	var resolved JSON
+	err := db.Get(&resolved, `
+		select
+			resolved
+		from
+			item
+		where
+			dataset_id = $1
+			and id = $2
+		order by
+			game_object_id desc
+		limit 1
+	`, dataset, id)
+	if err == sql.ErrNoRows {
+		return nil, &NotFoundError{What: "item " + id}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return DecodeItemType(resolved)
+}
+
+// GetItem returns the definition of an item or abstract along with where it
+// came from. When a mod redefines the item its last definition wins.
+func (db *DB) GetItem(version, id string, langs []string) (*ItemDetail, error) {
+	dataset, err := db.datasetID(version)
+	if err != nil {
+		return nil, err
+	}
+
+	item := &ItemDetail{}
+	err = db.Get(item, `
+		select
+			coalesce(id, '') as id,
+			coalesce(abstract, '') as abstract,
+			type,
+			source,
+			mod,
+			coalesce(ancestry, '{}') as ancestry,
+			raw,
+			resolved
+		from
+			item
+		where
+			dataset_id = $1
+			and (id = $2 or abstract = $2)
+		order by
+			game_object_id desc
+		limit 1
+	`, dataset, id)
+	if err == sql.ErrNoRows {
+		return nil, &NotFoundError{What: "item " + id}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	item.Translation, err = db.translation(dataset, langs, "item", item.ID)
+	if err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// GetDiff compares the resolved definitions of two datasets. Where a mod
+// redefines an object only its last definition counts, and objects without
+// an id can't be matched up so are left out.
+func (db *DB) GetDiff(from, to string) (*Diff, error) {
+	fromID, err := db.datasetID(from)
+	if err != nil {
+		return nil, err
+	}
+	toID, err := db.datasetID(to)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := []struct {
+		Type string `db:"type"`
+		ID   string `db:"id"`
+		From JSON   `db:"from_data"`
+		To   JSON   `db:"to_data"`
+	}{}
+	err = db.Select(&rows, `
+		with
+			a as (
+				select distinct on (type, key)
+					type,
+					coalesce(abstract, id) as key,
+					coalesce(resolved, raw) as data
+				from
+					game_object
+				where
+					dataset_id = $1
+					and coalesce(abstract, id) is not null
+				order by
+					type, key, game_object_id desc
+			),
+			b as (
+				select distinct on (type, key)
+					type,
+					coalesce(abstract, id) as key,
+					coalesce(resolved, raw) as data
+				from
+					game_object
+				where
+					dataset_id = $2
+					and coalesce(abstract, id) is not null
+				order by
+					type, key, game_object_id desc
+			)
+		select
+			coalesce(a.type, b.type) as type,
+			coalesce(a.key, b.key) as id,
+			a.data as from_data,
+			b.data as to_data
+		from
+			a
+			full outer join b on a.type = b.type and a.key = b.key
+		where
+			a.data is distinct from b.data
+	`, fromID, toID)
+	if err != nil {
+		return nil, err
+	}
+
+	pairs := make([]ObjectPair, 0, len(rows))
+	for _, r := range rows {
+		p := ObjectPair{Type: r.Type, ID: r.ID}
+		if p.From, err = decodeJSON(r.From); err != nil {
+			return nil, err
+		}
+		if p.To, err = decodeJSON(r.To); err != nil {
+			return nil, err
+		}
+		pairs = append(pairs, p)
+	}
+
+	return BuildDiff(from, to, pairs), nil
+}
+
+// SearchItems finds the items whose id, name or description contain every
+// word of q, best matches first. Names are given in lang when they have been
+// translated, though matching and snippets stay in English.
+func (db *DB) SearchItems(version, q string, types []string, limit int, lang string) (*SearchResults, error) {
+	dataset, err := db.datasetID(version)
+	if err != nil {
+		return nil, err
+	}
+
+	query, err := searchQuery(q)
+	if err != nil {
+		return nil, err
+	}
+
+	results := &SearchResults{
+		Query:   q,
+		Types:   []*TypeCount{},
+		Results: []*SearchHit{},
+	}
+
+	err = db.Select(&results.Types, `
+		select
+			type,
+			count(*) as count
+		from
 			item
+		where
+			dataset_id = $1
+			and search @@ to_tsquery('english', $2)
+		group by
+			type
+		order by
+			type
+	`, dataset, query)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range results.Types {
+		results.Total += t.Count
+	}
+
+	err = db.Select(&results.Results, `
+		select
+			coalesce(id, abstract) as id,
+			type,
+			coalesce(resolved#>>'{name,str}', resolved->>'name', '') as name,
+			ts_rank_cd(search, q) as rank,
+			ts_headline(
+				'english',
+				coalesce(resolved#>>'{name,str}', resolved->>'name', '') || '. ' || coalesce(resolved->>'description', ''),
+				q,
+				'StartSel=<mark>, StopSel=</mark>, MaxFragments=2'
+			) as snippet
+		from
+			item,
+			to_tsquery('english', $2) as q
+		where
+			dataset_id = $1
+			and search @@ q
+			and (cardinality($3::text[]) = 0 or type = any($3))
+		order by
+			rank desc,
+			id
+		limit $4
+	`, dataset, query, pq.Array(types), limit)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, len(results.Results))
+	for i, h := range results.Results {
+		ids[i] = h.ID
+	}
+	names, err := db.translatedNames(dataset, lang, "item", ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, h := range results.Results {
+		if n, ok := names[h.ID]; ok {
+			h.Name = n
+		}
+	}
+
+	return results, nil
+}
+
+// itemExists reports whether the dataset has an item or abstract called id.
+func (db *DB) itemExists(dataset int, id string) (bool, error) {
+	var exists bool
+	err := db.Get(&exists, `
+		select exists (
+			select
+				1
+			from
+				item
+			where
+				dataset_id = $1
+				and (id = $2 or abstract = $2)
+		)
+	`, dataset, id)
+	return exists, err
+}
+
+// GetRecipes returns the recipes that make an item.
+func (db *DB) GetRecipes(version, id, lang string) ([]*Recipe, error) {
+	return db.itemRecipes(version, id, lang, `
+		recipe_definition.recipe_type = 'recipe'
+		and recipe_definition.result = $2
+	`)
+}
+
+// GetUsedIn returns the recipes that take an item as a component or use it
+// as a tool.
+func (db *DB) GetUsedIn(version, id, lang string) ([]*Recipe, error) {
+	return db.itemRecipes(version, id, lang, `
+		recipe_definition.recipe_type = 'recipe'
+		and (
+			exists (
+				select
+					1
+				from
+					recipe_component c
+				where
+					c.dataset_id = recipe_definition.dataset_id
+					and c.recipe_type = recipe_definition.recipe_type
+					and c.recipe_id = recipe_definition.recipe_id
+					and c.item_id = $2
+			)
+			or exists (
+				select
+					1
+				from
+					recipe_tool t
+				where
+					t.dataset_id = recipe_definition.dataset_id
+					and t.recipe_type = recipe_definition.recipe_type
+					and t.recipe_id = recipe_definition.recipe_id
+					and t.item_id = $2
+			)
+		)
+	`)
+}
+
+// GetDisassembly returns the ways to take an item apart: its uncrafts, and
+// any recipe for it that can be reversed.
+func (db *DB) GetDisassembly(version, id, lang string) ([]*Recipe, error) {
+	return db.itemRecipes(version, id, lang, `
+		recipe_definition.result = $2
+		and (
+			recipe_definition.recipe_type = 'uncraft'
+			or recipe_definition.reversible
+		)
 	`)
+}
+
+// itemRecipes returns the recipes matching cond for the item id, named in
+// lang when their results' names have been translated.
+func (db *DB) itemRecipes(version, id, lang, cond string) ([]*Recipe, error) {
+	dataset, err := db.datasetID(version)
+	if err != nil {
+		return nil, err
+	}
+	exists, err := db.itemExists(dataset, id)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, &NotFoundError{What: "item " + id}
+	}
+	recipes, err := db.recipes(dataset, cond, id)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]string, len(recipes))
+	for i, r := range recipes {
+		results[i] = r.Result
+	}
+	names, err := db.translatedNames(dataset, lang, "item", results)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range recipes {
+		if n, ok := names[r.Result]; ok {
+			r.Name = n
+		}
+	}
+	return recipes, nil
+}
+
+// recipes returns the recipes of a dataset that match cond, filling in their
+// requirements. cond is written against recipe_definition and can use $2 for
+// arg.
+func (db *DB) recipes(dataset int, cond string, arg interface{}) ([]*Recipe, error) {
+	recipes := []*Recipe{}
+	err := db.Select(&recipes, `
+		select
+			recipe_type,
+			recipe_id,
+			result,
+			coalesce((
+				select
+					coalesce(i.resolved#>>'{name,str}', i.resolved->>'name')
+				from
+					item i
+				where
+					i.dataset_id = recipe_definition.dataset_id
+					and i.id = recipe_definition.result
+				order by
+					i.game_object_id desc
+				limit 1
+			), '') as name,
+			makes,
+			coalesce(category, '') as category,
+			coalesce(subcategory, '') as subcategory,
+			coalesce(skill_used, '') as skill_used,
+			difficulty,
+			time,
+			reversible
+		from
+			recipe_definition
+		where
+			dataset_id = $1
+			and (`+cond+`)
+		order by
+			recipe_type desc,
+			recipe_id
+	`, dataset, arg)
+	if err != nil {
+		return nil, err
+	}
+
+	byKey := make(map[recipeKey]*Recipe, len(recipes))
+	for _, r := range recipes {
+		r.Skills = []*RecipeSkill{}
+		r.Qualities = [][]*RecipeQuality{}
+		r.Tools = [][]*RecipeItem{}
+		r.Components = [][]*RecipeItem{}
+		byKey[recipeKey{r.Type, r.ID}] = r
+	}
+
+	chosen := `
+		(recipe_type, recipe_id) in (
+			select
+				recipe_type,
+				recipe_id
+			from
+				recipe_definition
+			where
+				dataset_id = $1
+				and (` + cond + `)
+		)
+	`
+
+	items := []struct {
+		RecipeItem
+		Type  string `db:"recipe_type"`
+		ID    string `db:"recipe_id"`
+		Group int    `db:"group_index"`
+	}{}
+	err = db.Select(&items, `
+		select
+			recipe_type,
+			recipe_id,
+			group_index,
+			item_id,
+			count,
+			coalesce(via, '') as via
+		from
+			recipe_component
+		where
+			dataset_id = $1
+			and `+chosen+`
+		order by
+			recipe_type, recipe_id, group_index, alternative_index
+	`, dataset, arg)
+	if err != nil {
+		return nil, err
+	}
+	for i := range items {
+		if r, ok := byKey[recipeKey{items[i].Type, items[i].ID}]; ok {
+			r.Components = groupedItem(r.Components, items[i].Group, &items[i].RecipeItem)
+		}
+	}
+
+	tools := items[:0:0]
+	err = db.Select(&tools, `
+		select
+			recipe_type,
+			recipe_id,
+			group_index,
+			item_id,
+			charges as count,
+			coalesce(via, '') as via
+		from
+			recipe_tool
+		where
+			dataset_id = $1
+			and `+chosen+`
+		order by
+			recipe_type, recipe_id, group_index, alternative_index
+	`, dataset, arg)
+	if err != nil {
+		return nil, err
+	}
+	for i := range tools {
+		if r, ok := byKey[recipeKey{tools[i].Type, tools[i].ID}]; ok {
+			r.Tools = groupedItem(r.Tools, tools[i].Group, &tools[i].RecipeItem)
+		}
+	}
+
+	qualities := []struct {
+		RecipeQuality
+		Type  string `db:"recipe_type"`
+		ID    string `db:"recipe_id"`
+		Group int    `db:"group_index"`
+	}{}
+	err = db.Select(&qualities, `
+		select
+			recipe_type,
+			recipe_id,
+			group_index,
+			quality_id,
+			level,
+			amount,
+			coalesce(via, '') as via
+		from
+			recipe_quality
+		where
+			dataset_id = $1
+			and `+chosen+`
+		order by
+			recipe_type, recipe_id, group_index, alternative_index
+	`, dataset, arg)
+	if err != nil {
+		return nil, err
+	}
+	for i := range qualities {
+		if r, ok := byKey[recipeKey{qualities[i].Type, qualities[i].ID}]; ok {
+			r.Qualities = groupedQuality(r.Qualities, qualities[i].Group, &qualities[i].RecipeQuality)
+		}
+	}
+
+	skills := []struct {
+		RecipeSkill
+		Type string `db:"recipe_type"`
+		ID   string `db:"recipe_id"`
+	}{}
+	err = db.Select(&skills, `
+		select
+			recipe_type,
+			recipe_id,
+			skill_id,
+			level
+		from
+			recipe_skill
+		where
+			dataset_id = $1
+			and `+chosen+`
+		order by
+			recipe_type, recipe_id, skill_id
+	`, dataset, arg)
+	if err != nil {
+		return nil, err
+	}
+	for i := range skills {
+		if r, ok := byKey[recipeKey{skills[i].Type, skills[i].ID}]; ok {
+			r.Skills = append(r.Skills, &skills[i].RecipeSkill)
+		}
+	}
+
+	return recipes, nil
+}
+
+// GetCraftPlan plans crafting an item from an inventory. The qualities of
+// the tools in the inventory count along with any posted on their own.
+func (db *DB) GetCraftPlan(version, id string, req *CraftRequest) (*CraftPlan, error) {
+	dataset, err := db.datasetID(version)
+	if err != nil {
+		return nil, err
+	}
+	exists, err := db.itemExists(dataset, id)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, &NotFoundError{What: "item " + id}
+	}
+
+	recipes, err := db.recipes(dataset, `recipe_definition.recipe_type = $2`, "recipe")
+	if err != nil {
+		return nil, err
+	}
+
+	ids := []string{}
+	for item, count := range req.Items {
+		if count > 0 {
+			ids = append(ids, item)
+		}
+	}
+	tools := []struct {
+		ID        string `db:"id"`
+		Qualities JSON   `db:"qualities"`
+	}{}
+	err = db.Select(&tools, `
+		select
+			id,
+			resolved->'qualities' as qualities
+		from
+			item
+		where
+			dataset_id = $1
+			and id = any($2)
+			and resolved ? 'qualities'
+	`, dataset, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+
+	qualities := copyCounts(req.Qualities)
+	for _, t := range tools {
+		var qs [][]interface{}
+		if err := json.Unmarshal(t.Qualities, &qs); err != nil {
+			continue
+		}
+		for _, q := range qs {
+			if len(q) != 2 {
+				continue
+			}
+			name, _ := q[0].(string)
+			level, _ := q[1].(float64)
+			if int(level) > qualities[name] {
+				qualities[name] = int(level)
+			}
+		}
+	}
+
+	withTools := *req
+	withTools.Qualities = qualities
+	return PlanCraft(id, recipesByResult(recipes), &withTools), nil
+}
+
+// itemGroups reads every item group of a dataset, the last definition of
+// each winning, parsing them once per dataset. Groups that don't parse are
+// logged and left out. The groups are shared, so callers mustn't change them.
+func (db *DB) itemGroups(dataset int) (map[string]*ItemGroup, error) {
+	db.groupsMu.Lock()
+	defer db.groupsMu.Unlock()
+	if groups, ok := db.groups[dataset]; ok {
+		return groups, nil
+	}
+
+	rows := []struct {
+		ID       string `db:"id"`
+		Resolved JSON   `db:"resolved"`
+	}{}
+	err := db.Select(&rows, `
+		select
+			id,
+			resolved
+		from
+			item_group
+		where
+			dataset_id = $1
+			and id is not null
+			and resolved is not null
+		order by
+			game_object_id
+	`, dataset)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := make(map[string]*ItemGroup, len(rows))
+	for _, r := range rows {
+		g, err := ParseItemGroup(r.Resolved)
+		if err != nil {
+			log.WithField("id", r.ID).WithField("err", err).Warn("Skipping item group that doesn't parse")
+			continue
+		}
+		g.ID = r.ID
+		groups[r.ID] = g
+	}
+
+	if db.groups == nil {
+		db.groups = make(map[int]map[string]*ItemGroup)
+	}
+	db.groups[dataset] = groups
+	return groups, nil
+}
+
+// GetItemGroupExpansion works out what an item group can spawn.
+func (db *DB) GetItemGroupExpansion(version, id string) (*ItemGroupExpansion, error) {
+	dataset, err := db.datasetID(version)
+	if err != nil {
+		return nil, err
+	}
+	groups, err := db.itemGroups(dataset)
+	if err != nil {
+		return nil, err
+	}
+	e := NewItemGroupExpander(groups).Expand(id)
+	if e == nil {
+		return nil, &NotFoundError{What: "item group " + id}
+	}
+	return e, nil
+}
+
+// GetItemSpawns lists the item groups that can spawn an item, directly or
+// through the groups they contain, most likely first.
+func (db *DB) GetItemSpawns(version, id string) ([]*Spawn, error) {
+	dataset, err := db.datasetID(version)
+	if err != nil {
+		return nil, err
+	}
+	exists, err := db.itemExists(dataset, id)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, &NotFoundError{What: "item " + id}
+	}
+
+	spawners := []string{}
+	err = db.Select(&spawners, `
+		with recursive spawner (group_id) as (
+			select
+				group_id
+			from
+				item_group_member
+			where
+				dataset_id = $1
+				and member_type = 'item'
+				and member_id = $2
+			union
+			select
+				m.group_id
+			from
+				item_group_member m
+				join spawner s on m.member_id = s.group_id
+			where
+				m.dataset_id = $1
+				and m.member_type = 'group'
+		)
+		select
+			group_id
+		from
+			spawner
+	`, dataset, id)
+	if err != nil {
+		return nil, err
+	}
+
+	groups, err := db.itemGroups(dataset)
+	if err != nil {
+		return nil, err
+	}
+	x := NewItemGroupExpander(groups)
+
+	spawns := []*Spawn{}
+	for _, g := range spawners {
+		e := x.Expand(g)
+		if e == nil {
+			continue
+		}
+		for _, s := range e.Items {
+			if s.Item == id && s.Probability > 0 {
+				s.Group = g
+				spawns = append(spawns, s)
+			}
+		}
+	}
+	sort.Slice(spawns, func(i, j int) bool {
+		if spawns[i].Probability == spawns[j].Probability {
+			return spawns[i].Group < spawns[j].Group
+		}
+		return spawns[i].Probability > spawns[j].Probability
+	})
+	return spawns, nil
+}
+
+// GetMonsters lists every monster with its computed difficulty, in id order,
+// named in lang where that has been translated. Monsters that don't parse are
+// logged and left out.
+func (db *DB) GetMonsters(version, lang string) ([]*MonsterSummary, error) {
+	dataset, err := db.datasetID(version)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := []struct {
+		ID       string `db:"id"`
+		Resolved JSON   `db:"resolved"`
+	}{}
+	err = db.Select(&rows, `
+		select distinct on (id)
+			id,
+			resolved
+		from
+			monster
+		where
+			dataset_id = $1
+			and id is not null
+			and resolved is not null
+		order by
+			id,
+			game_object_id desc
+	`, dataset)
+	if err != nil {
+		return nil, err
+	}
+
+	monsters := []*MonsterSummary{}
+	for _, r := range rows {
+		m, err := ParseMonster(r.Resolved)
+		if err != nil {
+			log.WithField("id", r.ID).WithField("err", err).Warn("Skipping monster that doesn't parse")
+			continue
+		}
+		monsters = append(monsters, m.Summary())
+	}
+
+	ids := make([]string, len(monsters))
+	for i, m := range monsters {
+		ids[i] = m.ID
+	}
+	names, err := db.translatedNames(dataset, lang, "MONSTER", ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range monsters {
+		if n, ok := names[m.ID]; ok {
+			m.Name = n
+		}
+	}
+	return monsters, nil
+}
+
+// GetMonster returns a monster with its computed values, the species and
+// faction it belongs to, and what it drops when it dies.
+func (db *DB) GetMonster(version, id string, langs []string) (*Monster, error) {
+	dataset, err := db.datasetID(version)
 	if err != nil {
 		return nil, err
 	}
-	return items, nil
+
+	resolved, err := db.lastResolved(dataset, "monster", id)
+	if err == sql.ErrNoRows {
+		return nil, &NotFoundError{What: "monster " + id}
+	}
+	if err != nil {
+		return nil, err
+	}
+	m, err := ParseMonster(resolved)
+	if err != nil {
+		return nil, err
+	}
+
+	m.SpeciesDetail = []*Species{}
+	for _, species := range m.Species {
+		resolved, err := db.lastResolved(dataset, "species", species)
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		s, err := parseSpecies(resolved)
+		if err != nil {
+			return nil, err
+		}
+		m.SpeciesDetail = append(m.SpeciesDetail, s)
+	}
+
+	if m.Faction != "" {
+		resolved, err := db.lastResolved(dataset, "monster_faction", m.Faction)
+		if err != nil && err != sql.ErrNoRows {
+			return nil, err
+		}
+		if err == nil {
+			if m.FactionDetail, err = parseMonsterFaction(resolved); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if len(m.deathDrops) > 0 {
+		groups, err := db.itemGroups(dataset)
+		if err != nil {
+			return nil, err
+		}
+		m.expandDeathDrops(NewItemGroupExpander(groups))
+	}
+
+	m.Translation, err = db.translation(dataset, langs, "MONSTER", m.ID)
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// GetMonsterGroup returns what a monster group spawns, and the overmap
+// terrain, overmap specials and mapgen that spawn the group.
+func (db *DB) GetMonsterGroup(version, id string) (*MonsterGroup, error) {
+	dataset, err := db.datasetID(version)
+	if err != nil {
+		return nil, err
+	}
+
+	resolved, err := db.lastResolved(dataset, "monstergroup", id)
+	if err == sql.ErrNoRows {
+		return nil, &NotFoundError{What: "monster group " + id}
+	}
+	if err != nil {
+		return nil, err
+	}
+	g, err := ParseMonsterGroup(resolved)
+	if err != nil {
+		return nil, err
+	}
+	g.ID = id
+
+	// Overmap terrain and specials spawn a group through spawns, mapgen with
+	// place_monsters or a monsters legend entry, either of one placement or a
+	// list of them.
+	err = db.Select(&g.Locations, `
+		select
+			type,
+			coalesce(id, '') as id,
+			source
+		from
+			game_object
+		where
+			dataset_id = $1
+			and (
+				(
+					type in ('overmap_terrain', 'overmap_special')
+					and resolved->'spawns'->>'group' = $2
+				)
+				or (
+					type = 'mapgen'
+					and (
+						resolved->'object'->'place_monsters' @> jsonb_build_array(jsonb_build_object('monster', $2::text))
+						or exists (
+							select
+								1
+							from
+								jsonb_each(case jsonb_typeof(resolved->'object'->'monsters') when 'object' then resolved->'object'->'monsters' else '{}' end) as legend
+							where
+								legend.value @> jsonb_build_object('monster', $2::text)
+								or legend.value @> jsonb_build_array(jsonb_build_object('monster', $2::text))
+						)
+					)
+				)
+			)
+		order by
+			type,
+			id,
+			source
+	`, dataset, id)
+	if err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// lastResolved reads the resolved definition of id from one of the object
+// views, the last definition winning when a mod redefines it.
+func (db *DB) lastResolved(dataset int, view, id string) (JSON, error) {
+	var resolved JSON
+	err := db.Get(&resolved, fmt.Sprintf(`
+		select
+			resolved
+		from
+			%s
+		where
+			dataset_id = $1
+			and id = $2
+			and resolved is not null
+		order by
+			game_object_id desc
+		limit 1
+	`, view), dataset, id)
+	return resolved, err
+}
+
+// GetVehicle assembles a prebuilt vehicle from its parts and adds up its
+// mass, fuel capacity and engine power.
+func (db *DB) GetVehicle(version, id string) (*Vehicle, error) {
+	dataset, err := db.datasetID(version)
+	if err != nil {
+		return nil, err
+	}
+
+	resolved, err := db.lastResolved(dataset, "vehicle", id)
+	if err == sql.ErrNoRows {
+		return nil, &NotFoundError{What: "vehicle " + id}
+	}
+	if err != nil {
+		return nil, err
+	}
+	v, err := ParseVehicle(resolved)
+	if err != nil {
+		return nil, err
+	}
+
+	parts, err := db.vehicleParts(dataset, v.PartIDs())
+	if err != nil {
+		return nil, err
+	}
+	v.Assemble(parts)
+	return v, nil
+}
+
+// GetVehiclePart returns a vehicle part with the item it is installed from,
+// what installing it takes and what it breaks into.
+func (db *DB) GetVehiclePart(version, id string) (*VehiclePart, error) {
+	dataset, err := db.datasetID(version)
+	if err != nil {
+		return nil, err
+	}
+
+	parts, err := db.vehicleParts(dataset, []string{id})
+	if err != nil {
+		return nil, err
+	}
+	p, ok := parts[id]
+	if !ok {
+		return nil, &NotFoundError{What: "vehicle part " + id}
+	}
+
+	installs, err := db.recipes(dataset, `
+		recipe_definition.recipe_type = 'vehicle_part_install'
+		and recipe_definition.recipe_id = $2
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(installs) > 0 {
+		p.Install = installs[0]
+	}
+
+	if len(p.breaksInto) > 0 {
+		groups, err := db.itemGroups(dataset)
+		if err != nil {
+			return nil, err
+		}
+		p.expandBreaksInto(NewItemGroupExpander(groups))
+	}
+	return p, nil
+}
+
+// vehicleParts reads the named vehicle parts along with the items they are
+// installed from, the last definition of each winning. Parts that don't
+// parse are logged and left out, as parts that aren't defined are.
+func (db *DB) vehicleParts(dataset int, ids []string) (map[string]*VehiclePart, error) {
+	rows := []struct {
+		ID       string `db:"id"`
+		Resolved JSON   `db:"resolved"`
+	}{}
+	err := db.Select(&rows, `
+		select distinct on (id)
+			id,
+			resolved
+		from
+			vehicle_part
+		where
+			dataset_id = $1
+			and id = any($2)
+			and resolved is not null
+		order by
+			id,
+			game_object_id desc
+	`, dataset, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+
+	parts := make(map[string]*VehiclePart, len(rows))
+	items := []string{}
+	for _, r := range rows {
+		p, err := ParseVehiclePart(r.Resolved)
+		if err != nil {
+			log.WithField("id", r.ID).WithField("err", err).Warn("Skipping vehicle part that doesn't parse")
+			continue
+		}
+		parts[p.ID] = p
+		items = append(items, p.Item.ID)
+	}
+
+	found := []*PartItem{}
+	err = db.Select(&found, `
+		select distinct on (id)
+			id,
+			coalesce(resolved#>>'{name,str}', resolved->>'name', '') as name,
+			weight
+		from
+			item
+		where
+			dataset_id = $1
+			and id = any($2)
+		order by
+			id,
+			game_object_id desc
+	`, dataset, pq.Array(items))
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[string]*PartItem, len(found))
+	for _, i := range found {
+		byID[i.ID] = i
+	}
+	for _, p := range parts {
+		if i, ok := byID[p.Item.ID]; ok {
+			p.Item = i
+		}
+	}
+	return parts, nil
+}
+
+// GetTerrain returns a terrain with the items bashing and deconstructing it
+// give, named in lang where that has been translated.
+func (db *DB) GetTerrain(version, id, lang string) (*Terrain, error) {
+	dataset, err := db.datasetID(version)
+	if err != nil {
+		return nil, err
+	}
+
+	resolved, err := db.lastResolved(dataset, "terrain", id)
+	if err == sql.ErrNoRows {
+		return nil, &NotFoundError{What: "terrain " + id}
+	}
+	if err != nil {
+		return nil, err
+	}
+	t, err := ParseTerrain(resolved)
+	if err != nil {
+		return nil, err
+	}
+
+	if hasYields(t.Bash, t.Deconstruct) {
+		groups, err := db.itemGroups(dataset)
+		if err != nil {
+			return nil, err
+		}
+		expandYields(NewItemGroupExpander(groups), t.ID, t.Bash, t.Deconstruct)
+	}
+
+	names, err := db.translatedNames(dataset, lang, "terrain", []string{t.ID})
+	if err != nil {
+		return nil, err
+	}
+	if n, ok := names[t.ID]; ok {
+		t.Name = n
+	}
+	return t, nil
+}
+
+// GetFurniture returns a piece of furniture with the items bashing and
+// deconstructing it give, named in lang where that has been translated.
+func (db *DB) GetFurniture(version, id, lang string) (*Furniture, error) {
+	dataset, err := db.datasetID(version)
+	if err != nil {
+		return nil, err
+	}
+
+	resolved, err := db.lastResolved(dataset, "furniture", id)
+	if err == sql.ErrNoRows {
+		return nil, &NotFoundError{What: "furniture " + id}
+	}
+	if err != nil {
+		return nil, err
+	}
+	f, err := ParseFurniture(resolved)
+	if err != nil {
+		return nil, err
+	}
+
+	if hasYields(f.Bash, f.Deconstruct) {
+		groups, err := db.itemGroups(dataset)
+		if err != nil {
+			return nil, err
+		}
+		expandYields(NewItemGroupExpander(groups), f.ID, f.Bash, f.Deconstruct)
+	}
+
+	names, err := db.translatedNames(dataset, lang, "furniture", []string{f.ID})
+	if err != nil {
+		return nil, err
+	}
+	if n, ok := names[f.ID]; ok {
+		f.Name = n
+	}
+	return f, nil
+}
+
+// GetMutationTree returns the graph of mutations around a mutation, named in
+// lang where that has been translated.
+func (db *DB) GetMutationTree(version, id, lang string) (*MutationGraph, error) {
+	dataset, err := db.datasetID(version)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := []struct {
+		ID       string `db:"id"`
+		Resolved JSON   `db:"resolved"`
+	}{}
+	err = db.Select(&rows, `
+		select distinct on (id)
+			id,
+			resolved
+		from
+			mutation
+		where
+			dataset_id = $1
+			and id is not null
+			and resolved is not null
+		order by
+			id,
+			game_object_id desc
+	`, dataset)
+	if err != nil {
+		return nil, err
+	}
+
+	// A mutation that doesn't parse is left out of the tree rather than
+	// failing the whole request.
+	mutations := make(map[string]*Mutation, len(rows))
+	for _, r := range rows {
+		m, err := ParseMutation(r.Resolved)
+		if err != nil {
+			log.WithField("id", r.ID).WithField("err", err).Warn("Skipping mutation that doesn't parse")
+			continue
+		}
+		mutations[m.ID] = m
+	}
+	if _, ok := mutations[id]; !ok {
+		return nil, &NotFoundError{What: "mutation " + id}
+	}
+	g := MutationTree(id, mutations)
+
+	ids := make([]string, len(g.Nodes))
+	for i, n := range g.Nodes {
+		ids[i] = n.ID
+	}
+	names, err := db.translatedNames(dataset, lang, "mutation", ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, n := range g.Nodes {
+		if name, ok := names[n.ID]; ok {
+			n.Name = name
+		}
+	}
+	return g, nil
+}
+
+// GetMutationCategories lists the mutation categories in id order, named in
+// lang where that has been translated.
+func (db *DB) GetMutationCategories(version, lang string) ([]*MutationCategory, error) {
+	dataset, err := db.datasetID(version)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := []struct {
+		ID       string `db:"id"`
+		Resolved JSON   `db:"resolved"`
+	}{}
+	err = db.Select(&rows, `
+		select distinct on (id)
+			id,
+			resolved
+		from
+			mutation_category
+		where
+			dataset_id = $1
+			and id is not null
+			and resolved is not null
+		order by
+			id,
+			game_object_id desc
+	`, dataset)
+	if err != nil {
+		return nil, err
+	}
+
+	categories := []*MutationCategory{}
+	for _, r := range rows {
+		c, err := parseMutationCategory(r.Resolved)
+		if err != nil {
+			log.WithField("id", r.ID).WithField("err", err).Warn("Skipping mutation category that doesn't parse")
+			continue
+		}
+		categories = append(categories, c)
+	}
+
+	ids := make([]string, len(categories))
+	for i, c := range categories {
+		ids[i] = c.ID
+	}
+	names, err := db.translatedNames(dataset, lang, "mutation_category", ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range categories {
+		if n, ok := names[c.ID]; ok {
+			c.Name = n
+		}
+	}
+	return categories, nil
+}
+
+// GetBionics lists every bionic with the CBM that installs it, named in lang
+// where that has been translated.
+func (db *DB) GetBionics(version, lang string) ([]*Bionic, error) {
+	dataset, err := db.datasetID(version)
+	if err != nil {
+		return nil, err
+	}
+	return db.bionics(dataset, "", lang)
+}
+
+// GetBionic returns a bionic with the CBM that installs it, named in lang
+// where that has been translated.
+func (db *DB) GetBionic(version, id, lang string) (*Bionic, error) {
+	dataset, err := db.datasetID(version)
+	if err != nil {
+		return nil, err
+	}
+	bionics, err := db.bionics(dataset, id, lang)
+	if err != nil {
+		return nil, err
+	}
+	if len(bionics) == 0 {
+		return nil, &NotFoundError{What: "bionic " + id}
+	}
+	return bionics[0], nil
+}
+
+// bionics reads the bionics of a dataset, or only the one called id when it
+// is given. A CBM installs a bionic when it names it in bionic_id or, in
+// older data, shares its id. Bionics that don't parse are logged and left
+// out.
+func (db *DB) bionics(dataset int, id, lang string) ([]*Bionic, error) {
+	rows := []struct {
+		ID             string         `db:"id"`
+		Resolved       JSON           `db:"resolved"`
+		Capacity       *int64         `db:"capacity"`
+		ItemID         sql.NullString `db:"item_id"`
+		ItemName       string         `db:"item_name"`
+		ItemDifficulty int            `db:"item_difficulty"`
+		ItemIsUpgrade  bool           `db:"item_is_upgrade"`
+	}{}
+	err := db.Select(&rows, `
+		select distinct on (b.id)
+			b.id,
+			b.resolved,
+			b.capacity,
+			i.id as item_id,
+			coalesce(i.resolved#>>'{name,str}', i.resolved->>'name', '') as item_name,
+			coalesce((i.resolved->>'difficulty')::integer, 0) as item_difficulty,
+			coalesce((i.resolved->>'is_upgrade')::boolean, false) as item_is_upgrade
+		from
+			bionic b
+			left join lateral (
+				select
+					id,
+					resolved
+				from
+					item
+				where
+					dataset_id = b.dataset_id
+					and type = 'BIONIC_ITEM'
+					and (id = b.id or resolved->>'bionic_id' = b.id)
+				order by
+					game_object_id desc
+				limit 1
+			) i on true
+		where
+			b.dataset_id = $1
+			and b.id is not null
+			and b.resolved is not null
+			and ($2 = '' or b.id = $2)
+		order by
+			b.id,
+			b.game_object_id desc
+	`, dataset, id)
+	if err != nil {
+		return nil, err
+	}
+
+	bionics := []*Bionic{}
+	for _, r := range rows {
+		b, err := ParseBionic(r.Resolved)
+		if err != nil {
+			log.WithField("id", r.ID).WithField("err", err).Warn("Skipping bionic that doesn't parse")
+			continue
+		}
+		b.Capacity = r.Capacity
+		if r.ItemID.Valid {
+			b.Item = &BionicItem{ID: r.ItemID.String, Name: r.ItemName, Difficulty: r.ItemDifficulty, IsUpgrade: r.ItemIsUpgrade}
+		}
+		bionics = append(bionics, b)
+	}
+
+	ids := make([]string, 0, len(bionics))
+	itemIDs := []string{}
+	for _, b := range bionics {
+		ids = append(ids, b.ID)
+		if b.Item != nil {
+			itemIDs = append(itemIDs, b.Item.ID)
+		}
+	}
+	names, err := db.translatedNames(dataset, lang, "bionic", ids)
+	if err != nil {
+		return nil, err
+	}
+	itemNames, err := db.translatedNames(dataset, lang, "item", itemIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, b := range bionics {
+		if n, ok := names[b.ID]; ok {
+			b.Name = n
+		}
+		if b.Item == nil {
+			continue
+		}
+		if n, ok := itemNames[b.Item.ID]; ok {
+			b.Item.Name = n
+		}
+	}
+	return bionics, nil
+}
+
+// GetReferences lists every place an object's definition names id.
+func (db *DB) GetReferences(version, id string) ([]*Reference, error) {
+	dataset, err := db.datasetID(version)
+	if err != nil {
+		return nil, err
+	}
+
+	var exists bool
+	err = db.Get(&exists, `
+		select exists (
+			select
+				1
+			from
+				game_object
+			where
+				dataset_id = $1
+				and (id = $2 or abstract = $2)
+		)
+	`, dataset, id)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, &NotFoundError{What: "object " + id}
+	}
+
+	refs := []*Reference{}
+	err = db.Select(&refs, `
+		select
+			object_type,
+			coalesce(object_id, '') as object_id,
+			source,
+			mod,
+			path
+		from
+			object_ref
+		where
+			dataset_id = $1
+			and ref_id = $2
+		order by
+			object_type,
+			object_id,
+			source,
+			path
+	`, dataset, id)
+	if err != nil {
+		return nil, err
+	}
+	return refs, nil
+}
+
+// materials reads every material of a dataset keyed by id.
+func (db *DB) materials(dataset int) (map[string]*Material, error) {
+	rows := []struct {
+		ID       string `db:"id"`
+		Resolved JSON   `db:"resolved"`
+	}{}
+	err := db.Select(&rows, `
+		select
+			coalesce(id, '') as id,
+			resolved
+		from
+			material
+		where
+			dataset_id = $1
+			and resolved is not null
+		order by
+			game_object_id
+	`, dataset)
+	if err != nil {
+		return nil, err
+	}
+
+	// A material that doesn't parse is left out rather than failing every
+	// request that looks materials up.
+	materials := make(map[string]*Material, len(rows))
+	for _, r := range rows {
+		m, err := ParseMaterial(r.Resolved)
+		if err != nil {
+			log.WithField("id", r.ID).WithField("err", err).Warn("Skipping material that doesn't parse")
+			continue
+		}
+		materials[m.ID] = m
+	}
+	return materials, nil
+}
+
+// GetItemProtection works out what a piece of armor protects against.
+func (db *DB) GetItemProtection(version, id string) (*Protection, error) {
+	dataset, err := db.datasetID(version)
+	if err != nil {
+		return nil, err
+	}
+	t, err := db.itemType(dataset, id)
+	if err != nil {
+		return nil, err
+	}
+	materials, err := db.materials(dataset)
+	if err != nil {
+		return nil, err
+	}
+	p := ArmorProtection(t, materials)
+	if p == nil {
+		return nil, &NotFoundError{What: "armor " + id}
+	}
+	return p, nil
+}
+
+// GetArmorRanking ranks the armor covering a body part by how well it
+// resists a damage type, best first.
+func (db *DB) GetArmorRanking(version, bodyPart, damage string, limit int) ([]*ArmorRanking, error) {
+	dataset, err := db.datasetID(version)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := []JSON{}
+	err = db.Select(&rows, `
+		select distinct on (id)
+			resolved
+		from
+			item
+		where
+			dataset_id = $1
+			and id is not null
+			and (resolved ? 'covers' or resolved #> '{armor_data,covers}' is not null)
+		order by
+			id,
+			game_object_id desc
+	`, dataset)
+	if err != nil {
+		return nil, err
+	}
+	materials, err := db.materials(dataset)
+	if err != nil {
+		return nil, err
+	}
+
+	armor := []*Protection{}
+	for _, r := range rows {
+		t, err := DecodeItemType(r)
+		if err != nil {
+			continue
+		}
+		if p := ArmorProtection(t, materials); p != nil {
+			armor = append(armor, p)
+		}
+	}
+
+	ranks, err := RankArmor(armor, bodyPart, damage)
+	if err != nil {
+		return nil, err
+	}
+	if len(ranks) > limit {
+		ranks = ranks[:limit]
+	}
+	return ranks, nil
+}
+
+// GetGunCompatibility lists the ammo, magazines and mods that fit a gun, or a
+// tool that takes ammo, with the ballistics of every round it can fire.
+func (db *DB) GetGunCompatibility(version, id string) (*Compatibility, error) {
+	dataset, err := db.datasetID(version)
+	if err != nil {
+		return nil, err
+	}
+	item, err := db.itemType(dataset, id)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := []JSON{}
+	err = db.Select(&rows, `
+		select distinct on (id)
+			resolved
+		from
+			item
+		where
+			dataset_id = $1
+			and id is not null
+			and type in ('AMMO', 'GUNMOD', 'TOOLMOD')
+		order by
+			id,
+			game_object_id desc
+	`, dataset)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := []*ItemType{}
+	for _, r := range rows {
+		t, err := DecodeItemType(r)
+		if err != nil {
+			continue
+		}
+		candidates = append(candidates, t)
+	}
+
+	c := GunCompatibility(item, candidates)
+	if c == nil {
+		return nil, &NotFoundError{What: "gun or tool taking ammo " + id}
+	}
+	return c, nil
+}
+
+// GetFood filters and ranks the comestibles of a dataset.
+func (db *DB) GetFood(q *FoodQuery) ([]*Food, error) {
+	dataset, err := db.datasetID(q.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	conds, args := q.where([]interface{}{dataset})
+	where := "dataset_id = $1"
+	for _, c := range conds {
+		where += "\n\t\t\tand " + c
+	}
+
+	food := []*Food{}
+	err = db.Select(&food, fmt.Sprintf(`
+		select
+			id,
+			mod,
+			name,
+			comestible_type,
+			charges,
+			calories,
+			quench,
+			fun,
+			stim,
+			healthy,
+			parasites,
+			radiation,
+			addiction_type,
+			addiction_potential,
+			spoils_in,
+			weight,
+			volume,
+			materials,
+			vitamins,
+			%s as kcal_per_kg,
+			%s as kcal_per_l
+		from
+			comestible
+		where
+			%s
+		order by
+			%s
+		limit %d
+	`, foodColumns["kcal_per_kg"], foodColumns["kcal_per_l"], where, q.orderBy(), q.Limit), args...)
+	if err != nil {
+		return nil, err
+	}
+	return food, nil
+}
+
+// GetLanguages lists the languages translations were loaded for.
+func (db *DB) GetLanguages(version string) ([]*Language, error) {
+	dataset, err := db.datasetID(version)
+	if err != nil {
+		return nil, err
+	}
+
+	languages := []*Language{}
+	err = db.Select(&languages, `
+		select
+			lang,
+			nplurals,
+			plural_forms
+		from
+			language
+		where
+			dataset_id = $1
+		order by
+			lang
+	`, dataset)
+	if err != nil {
+		return nil, err
+	}
+	return languages, nil
+}
+
+// Language picks the language to translate a response into: the first of
+// the accepted languages that has been loaded for the dataset, empty when
+// there is none.
+func (db *DB) Language(version string, accepted []string) (string, error) {
+	dataset, err := db.datasetID(version)
+	if err != nil {
+		return "", err
+	}
+	return db.language(dataset, accepted)
+}
+
+func (db *DB) language(dataset int, accepted []string) (string, error) {
+	if len(accepted) == 0 {
+		return "", nil
+	}
+	available := []string{}
+	err := db.Select(&available, `
+		select
+			lang
+		from
+			language
+		where
+			dataset_id = $1
+		order by
+			lang
+	`, dataset)
+	if err != nil {
+		return "", err
+	}
+	return matchLanguage(accepted, available), nil
+}
+
+// translation is the name and description of an object in the first of the
+// accepted languages that has been loaded, nil when there is no such
+// language or the object isn't translated in it.
+func (db *DB) translation(dataset int, accepted []string, namespace, id string) (*Localized, error) {
+	if id == "" {
+		return nil, nil
+	}
+	lang, err := db.language(dataset, accepted)
+	if err != nil {
+		return nil, err
+	}
+	if lang == "" {
+		return nil, nil
+	}
+
+	l := &Localized{}
+	err = db.Get(l, `
+		select
+			t.lang,
+			coalesce(t.name, '') as name,
+			t.name_forms,
+			l.plural_forms,
+			coalesce(t.description, '') as description
+		from
+			translation t
+			join language l on l.dataset_id = t.dataset_id and l.lang = t.lang
+		where
+			t.dataset_id = $1
+			and t.lang = $2
+			and t.namespace = $3
+			and t.id = $4
+	`, dataset, lang, namespace, id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// translatedNames looks up the names of the objects called ids in lang, in
+// one query for a whole list. Objects whose name isn't translated are left
+// out, and nothing is looked up when lang is empty.
+func (db *DB) translatedNames(dataset int, lang, namespace string, ids []string) (map[string]string, error) {
+	names := map[string]string{}
+	if lang == "" || len(ids) == 0 {
+		return names, nil
+	}
+	rows := []struct {
+		ID   string `db:"id"`
+		Name string `db:"name"`
+	}{}
+	err := db.Select(&rows, `
+		select
+			id,
+			name
+		from
+			translation
+		where
+			dataset_id = $1
+			and lang = $2
+			and namespace = $3
+			and id = any($4)
+			and name <> ''
+	`, dataset, lang, namespace, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range rows {
+		names[r.ID] = r.Name
+	}
+	return names, nil
 }
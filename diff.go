@@ -0,0 +1,160 @@
+package cddadb
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// Diff is what changed between two loads of the game data, grouped by type.
+type Diff struct {
+	From  string      `json:"from"`
+	To    string      `json:"to"`
+	Types []*TypeDiff `json:"types"`
+}
+
+type TypeDiff struct {
+	Type     string          `json:"type"`
+	Added    []string        `json:"added"`
+	Removed  []string        `json:"removed"`
+	Modified []*ObjectChange `json:"modified"`
+}
+
+// ObjectChange lists the members of one object whose resolved definition
+// differs between the two loads.
+type ObjectChange struct {
+	ID     string         `json:"id"`
+	Fields []*FieldChange `json:"fields"`
+}
+
+// FieldChange is one changed member, named by its path from the top of the
+// definition like "damage[0].amount". A member that was added has no From and
+// one that was removed has no To.
+type FieldChange struct {
+	Path string      `json:"path"`
+	From interface{} `json:"from,omitempty"`
+	To   interface{} `json:"to,omitempty"`
+}
+
+// ObjectPair is the same object as found in each load, decoded from JSON. An
+// object missing from one side has nil there.
+type ObjectPair struct {
+	Type string
+	ID   string
+	From interface{}
+	To   interface{}
+}
+
+// BuildDiff sorts the pairs into a Diff, working out the changed members of
+// every object found on both sides.
+func BuildDiff(from, to string, pairs []ObjectPair) *Diff {
+	d := &Diff{From: from, To: to, Types: []*TypeDiff{}}
+	types := make(map[string]*TypeDiff)
+
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i].Type == pairs[j].Type {
+			return pairs[i].ID < pairs[j].ID
+		}
+		return pairs[i].Type < pairs[j].Type
+	})
+
+	for _, p := range pairs {
+		fields := DiffJSON(p.From, p.To)
+		if len(fields) == 0 {
+			continue
+		}
+
+		t, ok := types[p.Type]
+		if !ok {
+			t = &TypeDiff{
+				Type:     p.Type,
+				Added:    []string{},
+				Removed:  []string{},
+				Modified: []*ObjectChange{},
+			}
+			types[p.Type] = t
+			d.Types = append(d.Types, t)
+		}
+
+		switch {
+		case p.From == nil:
+			t.Added = append(t.Added, p.ID)
+		case p.To == nil:
+			t.Removed = append(t.Removed, p.ID)
+		default:
+			t.Modified = append(t.Modified, &ObjectChange{ID: p.ID, Fields: fields})
+		}
+	}
+
+	return d
+}
+
+// DiffJSON compares two decoded JSON values member by member. Lists are
+// compared entry by entry, so an entry inserted near the front shows up as a
+// change to every entry after it.
+func DiffJSON(from, to interface{}) []*FieldChange {
+	changes := []*FieldChange{}
+	diffJSON("", from, to, &changes)
+	return changes
+}
+
+func diffJSON(path string, from, to interface{}, changes *[]*FieldChange) {
+	switch f := from.(type) {
+	case map[string]interface{}:
+		if t, ok := to.(map[string]interface{}); ok {
+			keys := []string{}
+			for k := range f {
+				keys = append(keys, k)
+			}
+			for k := range t {
+				if _, ok := f[k]; !ok {
+					keys = append(keys, k)
+				}
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				p := k
+				if path != "" {
+					p = path + "." + k
+				}
+				diffJSON(p, f[k], t[k], changes)
+			}
+			return
+		}
+	case []interface{}:
+		if t, ok := to.([]interface{}); ok {
+			n := len(f)
+			if len(t) > n {
+				n = len(t)
+			}
+			for i := 0; i < n; i++ {
+				var fe, te interface{}
+				if i < len(f) {
+					fe = f[i]
+				}
+				if i < len(t) {
+					te = t[i]
+				}
+				diffJSON(fmt.Sprintf("%s[%d]", path, i), fe, te, changes)
+			}
+			return
+		}
+	}
+
+	if !reflect.DeepEqual(from, to) {
+		*changes = append(*changes, &FieldChange{Path: path, From: from, To: to})
+	}
+}
+
+// decodeJSON decodes a jsonb column for comparison, nil staying nil.
+func decodeJSON(j JSON) (interface{}, error) {
+	if len(j) == 0 {
+		return nil, nil
+	}
+	var v interface{}
+	if err := json.Unmarshal(j, &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
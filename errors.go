@@ -0,0 +1,21 @@
+package cddadb
+
+// NotFoundError is returned when the thing asked for doesn't exist, and is
+// served as a 404.
+type NotFoundError struct {
+	What string
+}
+
+func (e *NotFoundError) Error() string {
+	return e.What + " not found"
+}
+
+// BadRequestError is returned for a request that can't be answered as
+// asked, and is served as a 400.
+type BadRequestError struct {
+	Message string
+}
+
+func (e *BadRequestError) Error() string {
+	return e.Message
+}
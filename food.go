@@ -0,0 +1,222 @@
+package cddadb
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// Food is the comestible slot of a food, drink or medicine, along with how
+// many calories it packs into a kilogram and a litre. Calories and vitamins
+// are per charge.
+type Food struct {
+	ID                 string         `json:"id" db:"id"`
+	Mod                string         `json:"mod" db:"mod"`
+	Name               *string        `json:"name" db:"name"`
+	ComestibleType     *string        `json:"comestible_type" db:"comestible_type"`
+	Charges            int            `json:"charges" db:"charges"`
+	Calories           int            `json:"calories" db:"calories"`
+	Quench             int            `json:"quench" db:"quench"`
+	Fun                int            `json:"fun" db:"fun"`
+	Stim               int            `json:"stim" db:"stim"`
+	Healthy            int            `json:"healthy" db:"healthy"`
+	Parasites          int            `json:"parasites" db:"parasites"`
+	Radiation          int            `json:"radiation" db:"radiation"`
+	AddictionType      *string        `json:"addiction_type" db:"addiction_type"`
+	AddictionPotential int            `json:"addiction_potential" db:"addiction_potential"`
+	SpoilsIn           *int64         `json:"spoils_in" db:"spoils_in"`
+	Weight             *int64         `json:"weight" db:"weight"`
+	Volume             *int64         `json:"volume" db:"volume"`
+	Materials          pq.StringArray `json:"materials" db:"materials"`
+	Vitamins           JSON           `json:"vitamins" db:"vitamins"`
+	KcalPerKg          *float64       `json:"kcal_per_kg" db:"kcal_per_kg"`
+	KcalPerL           *float64       `json:"kcal_per_l" db:"kcal_per_l"`
+}
+
+// FoodQuery filters and ranks comestibles.
+//
+// Types and materials match any of the values given, and food made of any of
+// the excluded materials is left out, which is how allergens are avoided.
+// Perishable and Addictive are left unset to take either. Ranges compare the
+// numeric columns, and the vitamins as vitamin_<id>.
+type FoodQuery struct {
+	Version          string
+	Types            []string
+	Materials        []string
+	ExcludeMaterials []string
+	Perishable       *bool
+	Addictive        *bool
+	Ranges           []Range
+	Sort             string
+	Descending       bool
+	Limit            int
+}
+
+// foodColumns maps the columns food can be filtered and sorted by to their
+// expressions. Calories per kilogram are from the weight of one charge, per
+// litre from the volume of all the default charges.
+var foodColumns = map[string]string{
+	"calories":            "calories",
+	"quench":              "quench",
+	"fun":                 "fun",
+	"stim":                "stim",
+	"healthy":             "healthy",
+	"parasites":           "parasites",
+	"radiation":           "radiation",
+	"addiction_potential": "addiction_potential",
+	"charges":             "charges",
+	"spoils_in":           "spoils_in",
+	"weight":              "weight",
+	"volume":              "volume",
+	"kcal_per_kg":         "(calories * 1000.0 / nullif(weight, 0))",
+	"kcal_per_l":          "(calories * charges * 1000.0 / nullif(volume, 0))",
+}
+
+// ParseFoodQuery reads a FoodQuery from the query string of a request. Food
+// is ranked by calories per kilogram unless sorted otherwise.
+func ParseFoodQuery(q url.Values) (*FoodQuery, error) {
+	fq := &FoodQuery{
+		Version:          q.Get("version"),
+		Types:            list(q["comestible_type"]),
+		Materials:        list(q["material"]),
+		ExcludeMaterials: list(q["exclude_material"]),
+		Sort:             "kcal_per_kg",
+		Descending:       true,
+		Limit:            defaultItemLimit,
+	}
+
+	for _, b := range []struct {
+		name string
+		v    **bool
+	}{{"perishable", &fq.Perishable}, {"addictive", &fq.Addictive}} {
+		s := q.Get(b.name)
+		if s == "" {
+			continue
+		}
+		v, err := strconv.ParseBool(s)
+		if err != nil {
+			return nil, &BadRequestError{Message: fmt.Sprintf("%s must be true or false", b.name)}
+		}
+		*b.v = &v
+	}
+
+	if s := q.Get("sort"); s != "" {
+		fq.Descending = strings.HasPrefix(s, "-")
+		fq.Sort = strings.TrimPrefix(s, "-")
+		if _, ok := foodColumns[fq.Sort]; !ok && fq.Sort != "id" {
+			return nil, &BadRequestError{Message: fmt.Sprintf("can't sort by %s", fq.Sort)}
+		}
+	}
+
+	if s := q.Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 || n > maxItemLimit {
+			return nil, &BadRequestError{Message: fmt.Sprintf("limit must be between 1 and %d", maxItemLimit)}
+		}
+		fq.Limit = n
+	}
+
+	ranges := make(map[string]*Range)
+	fields := []string{}
+	for k, vs := range q {
+		var bound string
+		switch {
+		case strings.HasPrefix(k, "min_"):
+			bound = "min"
+		case strings.HasPrefix(k, "max_"):
+			bound = "max"
+		default:
+			continue
+		}
+		field := k[4:]
+		_, ok := foodColumns[field]
+		if !ok && !(strings.HasPrefix(field, "vitamin_") && fieldName.MatchString(field)) {
+			return nil, &BadRequestError{Message: fmt.Sprintf("can't filter on %s", field)}
+		}
+		v, err := rangeValue(field, vs[0])
+		if err != nil {
+			return nil, &BadRequestError{Message: fmt.Sprintf("%s: %v", k, err)}
+		}
+		r, ok := ranges[field]
+		if !ok {
+			r = &Range{Field: field}
+			ranges[field] = r
+			fields = append(fields, field)
+		}
+		if bound == "min" {
+			r.Min = &v
+		} else {
+			r.Max = &v
+		}
+	}
+	for _, f := range fields {
+		fq.Ranges = append(fq.Ranges, *ranges[f])
+	}
+
+	return fq, nil
+}
+
+// where builds the conditions of the query, numbering its arguments from
+// after the ones already in args.
+func (fq *FoodQuery) where(args []interface{}) ([]string, []interface{}) {
+	conds := []string{}
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if len(fq.Types) > 0 {
+		conds = append(conds, "comestible_type = any("+arg(pq.Array(fq.Types))+")")
+	}
+	if len(fq.Materials) > 0 {
+		conds = append(conds, "materials && "+arg(pq.Array(fq.Materials)))
+	}
+	if len(fq.ExcludeMaterials) > 0 {
+		conds = append(conds, "not (materials && "+arg(pq.Array(fq.ExcludeMaterials))+")")
+	}
+	if fq.Perishable != nil {
+		if *fq.Perishable {
+			conds = append(conds, "spoils_in is not null")
+		} else {
+			conds = append(conds, "spoils_in is null")
+		}
+	}
+	if fq.Addictive != nil {
+		if *fq.Addictive {
+			conds = append(conds, "addiction_potential > 0")
+		} else {
+			conds = append(conds, "addiction_potential = 0")
+		}
+	}
+
+	for _, r := range fq.Ranges {
+		expr, ok := foodColumns[r.Field]
+		if !ok {
+			expr = fmt.Sprintf("coalesce((vitamins->>%s::text)::numeric, 0)", arg(strings.TrimPrefix(r.Field, "vitamin_")))
+		}
+		if r.Min != nil {
+			conds = append(conds, expr+" >= "+arg(*r.Min))
+		}
+		if r.Max != nil {
+			conds = append(conds, expr+" <= "+arg(*r.Max))
+		}
+	}
+
+	return conds, args
+}
+
+// orderBy orders by the sort key, food missing it last and ties broken by
+// id.
+func (fq *FoodQuery) orderBy() string {
+	dir := "asc"
+	if fq.Descending {
+		dir = "desc"
+	}
+	if fq.Sort == "id" {
+		return "id " + dir
+	}
+	return fmt.Sprintf("%s %s nulls last, id", foodColumns[fq.Sort], dir)
+}
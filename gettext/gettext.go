@@ -0,0 +1,167 @@
+// Package gettext reads the game's translation catalogs, either the .po
+// files in lang/po or the .mo files msgfmt compiles them into, and looks up
+// messages in them the way the game's _(), pgettext() and ngettext() do.
+//
+// A catalog's Plural-Forms header says how many plural forms the language
+// has and which one a count takes, written as a C expression in n:
+//
+//	Plural-Forms: nplurals=3; plural=(n%10==1 && n%100!=11 ? 0 : n%10>=2 && n%10<=4 && (n%100<10 || n%100>=20) ? 1 : 2);
+//
+// Catalogs without the header use the English rule, singular for one and
+// plural otherwise.
+package gettext
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// contextSeparator joins a message's context to its id in a catalog key, as
+// msgfmt does.
+const contextSeparator = "\x04"
+
+// Catalog is the translations of one language.
+type Catalog struct {
+	// PluralForms is the Plural-Forms header as written.
+	PluralForms string
+	NPlurals    int
+	plural      func(n int) int
+	messages    map[string][]string
+}
+
+func newCatalog() *Catalog {
+	return &Catalog{
+		PluralForms: "nplurals=2; plural=(n != 1);",
+		NPlurals:    2,
+		plural:      english,
+		messages:    make(map[string][]string),
+	}
+}
+
+func english(n int) int {
+	if n == 1 {
+		return 0
+	}
+	return 1
+}
+
+// Load reads a .po or .mo file, going by its extension.
+func Load(path string) (*Catalog, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var c *Catalog
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".mo":
+		c, err = ParseMO(b)
+	case ".po":
+		c, err = ParsePO(b)
+	default:
+		return nil, fmt.Errorf("%s isn't a .po or .mo file", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return c, nil
+}
+
+// add records the translations of a message, keeping the header for the
+// plural forms and leaving out messages that aren't translated.
+func (c *Catalog) add(ctxt, id string, strs []string) error {
+	if ctxt == "" && id == "" {
+		return c.header(strs)
+	}
+	for _, s := range strs {
+		if s == "" {
+			return nil
+		}
+	}
+	if len(strs) == 0 {
+		return nil
+	}
+	c.messages[key(ctxt, id)] = strs
+	return nil
+}
+
+var pluralFormsHeader = regexp.MustCompile(`nplurals\s*=\s*(\d+)\s*;\s*plural\s*=\s*([^;]+);?`)
+
+func (c *Catalog) header(strs []string) error {
+	if len(strs) == 0 {
+		return nil
+	}
+	for _, line := range strings.Split(strs[0], "\n") {
+		i := strings.Index(line, ":")
+		if i < 0 || !strings.EqualFold(strings.TrimSpace(line[:i]), "Plural-Forms") {
+			continue
+		}
+		forms := strings.TrimSpace(line[i+1:])
+		m := pluralFormsHeader.FindStringSubmatch(forms)
+		if m == nil {
+			return fmt.Errorf("bad Plural-Forms %q", forms)
+		}
+		n, _ := strconv.Atoi(m[1])
+		plural, err := compilePlural(m[2])
+		if err != nil {
+			return fmt.Errorf("bad Plural-Forms %q: %v", forms, err)
+		}
+		c.PluralForms, c.NPlurals, c.plural = forms, n, plural
+	}
+	return nil
+}
+
+func key(ctxt, id string) string {
+	if ctxt == "" {
+		return id
+	}
+	return ctxt + contextSeparator + id
+}
+
+// Len is the number of translated messages.
+func (c *Catalog) Len() int {
+	return len(c.messages)
+}
+
+// Plural is the plural form a count takes.
+func (c *Catalog) Plural(n int) int {
+	p := c.plural(n)
+	if p < 0 || p >= c.NPlurals {
+		return 0
+	}
+	return p
+}
+
+// Forms returns every translated form of a message, a single one for
+// messages without a plural, nil when it isn't translated.
+func (c *Catalog) Forms(ctxt, id string) []string {
+	return c.messages[key(ctxt, id)]
+}
+
+// Gettext translates a message, returning the id when it isn't translated.
+func (c *Catalog) Gettext(ctxt, id string) string {
+	if strs := c.Forms(ctxt, id); len(strs) > 0 {
+		return strs[0]
+	}
+	return id
+}
+
+// NGettext translates a message for a count, falling back to the English
+// singular or plural when it isn't translated.
+func (c *Catalog) NGettext(ctxt, id, plural string, n int) string {
+	strs := c.Forms(ctxt, id)
+	if len(strs) == 0 {
+		if english(n) == 0 {
+			return id
+		}
+		return plural
+	}
+	p := c.Plural(n)
+	if p >= len(strs) {
+		return strs[0]
+	}
+	return strs[p]
+}
@@ -0,0 +1,70 @@
+package gettext
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const moMagic = 0x950412de
+
+// ParseMO reads a compiled .mo catalog in either byte order.
+func ParseMO(b []byte) (*Catalog, error) {
+	if len(b) < 28 {
+		return nil, errors.New("too short for a .mo file")
+	}
+	var order binary.ByteOrder
+	switch {
+	case binary.LittleEndian.Uint32(b) == moMagic:
+		order = binary.LittleEndian
+	case binary.BigEndian.Uint32(b) == moMagic:
+		order = binary.BigEndian
+	default:
+		return nil, errors.New("not a .mo file")
+	}
+	if rev := order.Uint32(b[4:]) >> 16; rev > 1 {
+		return nil, fmt.Errorf("unknown .mo revision %d", rev)
+	}
+	count := int(order.Uint32(b[8:]))
+	originals := int(order.Uint32(b[12:]))
+	translations := int(order.Uint32(b[16:]))
+
+	// str reads the i-th string of the table at offset.
+	str := func(table, i int) (string, error) {
+		at := table + i*8
+		if at < 0 || at+8 > len(b) {
+			return "", errors.New("string table runs past the end of the file")
+		}
+		n, off := int(order.Uint32(b[at:])), int(order.Uint32(b[at+4:]))
+		if off < 0 || n < 0 || off+n > len(b) {
+			return "", errors.New("string runs past the end of the file")
+		}
+		return string(b[off : off+n]), nil
+	}
+
+	c := newCatalog()
+	for i := 0; i < count; i++ {
+		orig, err := str(originals, i)
+		if err != nil {
+			return nil, err
+		}
+		trans, err := str(translations, i)
+		if err != nil {
+			return nil, err
+		}
+		// The original is the context, if any, then the id and its plural
+		// separated by a NUL, which only the id is looked up by.
+		var ctxt string
+		if j := strings.Index(orig, contextSeparator); j >= 0 {
+			ctxt, orig = orig[:j], orig[j+1:]
+		}
+		if j := strings.IndexByte(orig, 0); j >= 0 {
+			orig = orig[:j]
+		}
+		if err := c.add(ctxt, orig, strings.Split(trans, "\x00")); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
@@ -0,0 +1,237 @@
+package gettext
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// compilePlural turns the plural expression of a Plural-Forms header into a
+// function of the count. It understands the C operators gettext allows: the
+// conditional, logical, comparison and arithmetic operators, ! and
+// parentheses, over n and integer constants.
+func compilePlural(expr string) (func(n int) int, error) {
+	tokens, err := tokenize(expr)
+	if err != nil {
+		return nil, err
+	}
+	p := &pluralParser{tokens: tokens}
+	f, err := p.conditional()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos != len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q", p.tokens[p.pos])
+	}
+	return f, nil
+}
+
+func tokenize(expr string) ([]string, error) {
+	tokens := []string{}
+	for i := 0; i < len(expr); {
+		ch := expr[i]
+		switch {
+		case ch == ' ' || ch == '\t' || ch == '\n':
+			i++
+		case ch >= '0' && ch <= '9':
+			j := i
+			for j < len(expr) && expr[j] >= '0' && expr[j] <= '9' {
+				j++
+			}
+			tokens = append(tokens, expr[i:j])
+			i = j
+		case i+1 < len(expr) && isTwoCharOperator(expr[i:i+2]):
+			tokens = append(tokens, expr[i:i+2])
+			i += 2
+		case ch == 'n' || ch == '?' || ch == ':' || ch == '(' || ch == ')' || ch == '!' ||
+			ch == '<' || ch == '>' || ch == '+' || ch == '-' || ch == '*' || ch == '/' || ch == '%':
+			tokens = append(tokens, string(ch))
+			i++
+		default:
+			return nil, fmt.Errorf("unexpected %q", ch)
+		}
+	}
+	return tokens, nil
+}
+
+func isTwoCharOperator(s string) bool {
+	switch s {
+	case "==", "!=", "<=", ">=", "&&", "||":
+		return true
+	}
+	return false
+}
+
+type pluralParser struct {
+	tokens []string
+	pos    int
+}
+
+func (p *pluralParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *pluralParser) accept(ops ...string) (string, bool) {
+	t := p.peek()
+	for _, op := range ops {
+		if t == op {
+			p.pos++
+			return t, true
+		}
+	}
+	return "", false
+}
+
+func (p *pluralParser) conditional() (func(int) int, error) {
+	cond, err := p.binary(0)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := p.accept("?"); !ok {
+		return cond, nil
+	}
+	then, err := p.conditional()
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := p.accept(":"); !ok {
+		return nil, errors.New("conditional without :")
+	}
+	otherwise, err := p.conditional()
+	if err != nil {
+		return nil, err
+	}
+	return func(n int) int {
+		if cond(n) != 0 {
+			return then(n)
+		}
+		return otherwise(n)
+	}, nil
+}
+
+// precedence lists the binary operators from the loosest binding to the
+// tightest.
+var precedence = [][]string{
+	{"||"},
+	{"&&"},
+	{"==", "!="},
+	{"<", ">", "<=", ">="},
+	{"+", "-"},
+	{"*", "/", "%"},
+}
+
+func (p *pluralParser) binary(level int) (func(int) int, error) {
+	if level == len(precedence) {
+		return p.unary()
+	}
+	left, err := p.binary(level + 1)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.accept(precedence[level]...)
+		if !ok {
+			return left, nil
+		}
+		right, err := p.binary(level + 1)
+		if err != nil {
+			return nil, err
+		}
+		left = apply(op, left, right)
+	}
+}
+
+func apply(op string, l, r func(int) int) func(int) int {
+	b := func(v bool) int {
+		if v {
+			return 1
+		}
+		return 0
+	}
+	switch op {
+	case "||":
+		return func(n int) int { return b(l(n) != 0 || r(n) != 0) }
+	case "&&":
+		return func(n int) int { return b(l(n) != 0 && r(n) != 0) }
+	case "==":
+		return func(n int) int { return b(l(n) == r(n)) }
+	case "!=":
+		return func(n int) int { return b(l(n) != r(n)) }
+	case "<":
+		return func(n int) int { return b(l(n) < r(n)) }
+	case ">":
+		return func(n int) int { return b(l(n) > r(n)) }
+	case "<=":
+		return func(n int) int { return b(l(n) <= r(n)) }
+	case ">=":
+		return func(n int) int { return b(l(n) >= r(n)) }
+	case "+":
+		return func(n int) int { return l(n) + r(n) }
+	case "-":
+		return func(n int) int { return l(n) - r(n) }
+	case "*":
+		return func(n int) int { return l(n) * r(n) }
+	case "/":
+		return func(n int) int {
+			if d := r(n); d != 0 {
+				return l(n) / d
+			}
+			return 0
+		}
+	default:
+		return func(n int) int {
+			if d := r(n); d != 0 {
+				return l(n) % d
+			}
+			return 0
+		}
+	}
+}
+
+func (p *pluralParser) unary() (func(int) int, error) {
+	if _, ok := p.accept("!"); ok {
+		f, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return func(n int) int {
+			if f(n) == 0 {
+				return 1
+			}
+			return 0
+		}, nil
+	}
+	return p.primary()
+}
+
+func (p *pluralParser) primary() (func(int) int, error) {
+	t := p.peek()
+	switch {
+	case t == "":
+		return nil, errors.New("unexpected end of expression")
+	case t == "n":
+		p.pos++
+		return func(n int) int { return n }, nil
+	case t == "(":
+		p.pos++
+		f, err := p.conditional()
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := p.accept(")"); !ok {
+			return nil, errors.New("missing )")
+		}
+		return f, nil
+	case t[0] >= '0' && t[0] <= '9':
+		p.pos++
+		v, err := strconv.Atoi(t)
+		if err != nil {
+			return nil, err
+		}
+		return func(int) int { return v }, nil
+	}
+	return nil, fmt.Errorf("unexpected %q", t)
+}
@@ -0,0 +1,131 @@
+package gettext
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// poEntry is a message being read from a .po file.
+type poEntry struct {
+	fuzzy   bool
+	ctxt    string
+	id      string
+	strs    []string
+	started bool
+}
+
+// ParsePO reads a .po catalog. Fuzzy and obsolete entries are left out, as
+// msgfmt leaves them out of a .mo file.
+func ParsePO(b []byte) (*Catalog, error) {
+	c := newCatalog()
+	var e poEntry
+	// field points at the string the next continuation line adds to.
+	var field *string
+
+	flush := func() error {
+		if e.started && (!e.fuzzy || (e.ctxt == "" && e.id == "")) {
+			if err := c.add(e.ctxt, e.id, e.strs); err != nil {
+				return err
+			}
+		}
+		e = poEntry{}
+		field = nil
+		return nil
+	}
+
+	s := bufio.NewScanner(bytes.NewReader(b))
+	s.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	line := 0
+	for s.Scan() {
+		line++
+		l := strings.TrimSpace(s.Text())
+		switch {
+		case l == "":
+			continue
+		case strings.HasPrefix(l, "#,"):
+			// Flags come before the entry they describe.
+			if e.started {
+				if err := flush(); err != nil {
+					return nil, err
+				}
+			}
+			for _, f := range strings.Split(l[2:], ",") {
+				if strings.TrimSpace(f) == "fuzzy" {
+					e.fuzzy = true
+				}
+			}
+			continue
+		case strings.HasPrefix(l, "#"):
+			// Obsolete entries are commented out with #~ and skipped along
+			// with the other comments.
+			continue
+		}
+
+		keyword, rest := l, ""
+		if i := strings.IndexAny(l, " \t"); i >= 0 {
+			keyword, rest = l[:i], strings.TrimSpace(l[i:])
+		}
+		if strings.HasPrefix(l, `"`) {
+			keyword, rest = "", l
+		}
+		v, err := strconv.Unquote(rest)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: bad string %s", line, rest)
+		}
+
+		switch {
+		case keyword == "":
+			if field == nil {
+				return nil, fmt.Errorf("line %d: string outside of an entry", line)
+			}
+			*field += v
+		case keyword == "msgctxt":
+			// Flags read before the context belong to this entry, so only a
+			// previous entry is flushed.
+			if e.started {
+				if err := flush(); err != nil {
+					return nil, err
+				}
+			}
+			e.started = true
+			e.ctxt = v
+			field = &e.ctxt
+		case keyword == "msgid":
+			// An entry without a context starts at its msgid.
+			if e.started && (e.id != "" || len(e.strs) > 0) {
+				if err := flush(); err != nil {
+					return nil, err
+				}
+			}
+			e.started = true
+			e.id = v
+			field = &e.id
+		case keyword == "msgid_plural":
+			// Only the id is looked up, the English plural is the fallback
+			// the caller passes in.
+			field = new(string)
+		case keyword == "msgstr":
+			e.strs = append(e.strs, v)
+			field = &e.strs[len(e.strs)-1]
+		case strings.HasPrefix(keyword, "msgstr["):
+			n, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(keyword, "msgstr["), "]"))
+			if err != nil || n != len(e.strs) {
+				return nil, fmt.Errorf("line %d: unexpected %s", line, keyword)
+			}
+			e.strs = append(e.strs, v)
+			field = &e.strs[len(e.strs)-1]
+		default:
+			return nil, fmt.Errorf("line %d: unknown keyword %s", line, keyword)
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
@@ -0,0 +1,69 @@
+package gettext
+
+import (
+	"io/ioutil"
+	"testing"
+)
+
+func TestParsePO(t *testing.T) {
+	b, err := ioutil.ReadFile("testdata/ru.po")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := ParsePO(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if c.NPlurals != 3 {
+		t.Errorf("got %d plural forms, want 3", c.NPlurals)
+	}
+	if c.Len() != 5 {
+		t.Errorf("got %d messages, want 5", c.Len())
+	}
+
+	gettext := []struct {
+		ctxt string
+		id   string
+		want string
+	}{
+		{"", "pike", "щука"},
+		{"weapon", "pike", "пика"},
+		{"tool", "saw", "пила"},
+		{"", "A round fruit with red skin.", "Круглый фрукт с красной кожурой."},
+		// Fuzzy entries are left out, with and without a context.
+		{"", "pear", "pear"},
+		{"tool", "hammer", "hammer"},
+		// So are obsolete and untranslated ones.
+		{"", "rock", "rock"},
+		{"", "untranslated", "untranslated"},
+	}
+	for _, g := range gettext {
+		if got := c.Gettext(g.ctxt, g.id); got != g.want {
+			t.Errorf("Gettext(%q, %q) = %q, want %q", g.ctxt, g.id, got, g.want)
+		}
+	}
+
+	ngettext := []struct {
+		ctxt   string
+		id     string
+		plural string
+		n      int
+		want   string
+	}{
+		{"", "apple", "apples", 1, "яблоко"},
+		{"", "apple", "apples", 3, "яблока"},
+		{"", "apple", "apples", 5, "яблок"},
+		{"", "apple", "apples", 11, "яблок"},
+		{"", "apple", "apples", 21, "яблоко"},
+		{"", "apple", "apples", 22, "яблока"},
+		{"weapon", "pike", "pikes", 2, "пики"},
+		{"", "pear", "pears", 1, "pear"},
+		{"", "pear", "pears", 2, "pears"},
+	}
+	for _, g := range ngettext {
+		if got := c.NGettext(g.ctxt, g.id, g.plural, g.n); got != g.want {
+			t.Errorf("NGettext(%q, %q, %d) = %q, want %q", g.ctxt, g.id, g.n, got, g.want)
+		}
+	}
+}
@@ -0,0 +1,327 @@
+// Package inherit applies the game's copy-from inheritance to decoded JSON
+// definitions.
+//
+// A definition that copies from another starts out as a copy of its parent.
+// Members it gives itself replace the parent's, and four modifier blocks
+// change inherited values instead of replacing them:
+//
+//	relative      adds to a number, or to each number inside an object, where
+//	              a quantity with units like "10 g" can be added to a bare
+//	              number and the other way around
+//	proportional  multiplies a number, or each number inside an object
+//	extend        appends entries to a list
+//	delete        removes entries from a list
+//
+// Like assign() in the game, a relative change to a member wins over a
+// proportional one, which wins over setting the member explicitly; only one
+// of the three applies. Scaling a member the parent doesn't have leaves it at
+// its default.
+package inherit
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+)
+
+var modifiers = []string{"relative", "proportional", "extend", "delete"}
+
+func isModifier(k string) bool {
+	for _, m := range modifiers {
+		if k == m {
+			return true
+		}
+	}
+	return false
+}
+
+// Merge returns child layered over a copy of base. Members named in nested
+// are merged one level down, with their own modifier blocks, rather than
+// replaced. The identity of base and the copy-from link are not carried over.
+func Merge(base, child map[string]interface{}, nested map[string]bool) (map[string]interface{}, error) {
+	r := DeepCopy(base).(map[string]interface{})
+	delete(r, "abstract")
+	delete(r, "id")
+	for _, m := range modifiers {
+		delete(r, m)
+	}
+
+	if err := merge(r, child, nested); err != nil {
+		return nil, err
+	}
+
+	delete(r, "copy-from")
+	return r, nil
+}
+
+func merge(r, child map[string]interface{}, nested map[string]bool) error {
+	rel, _ := child["relative"].(map[string]interface{})
+	prop, _ := child["proportional"].(map[string]interface{})
+
+	for k, v := range child {
+		if isModifier(k) {
+			continue
+		}
+		if _, ok := rel[k]; ok {
+			continue
+		}
+		if _, ok := prop[k]; ok {
+			continue
+		}
+		if nested[k] {
+			bm, bok := r[k].(map[string]interface{})
+			cm, cok := v.(map[string]interface{})
+			if bok && cok {
+				if err := merge(bm, cm, nil); err != nil {
+					return fmt.Errorf("%s: %v", k, err)
+				}
+				continue
+			}
+		}
+		r[k] = DeepCopy(stripModifiers(v))
+	}
+
+	for k, v := range rel {
+		n, err := relative(r[k], v)
+		if err != nil {
+			return fmt.Errorf("relative %s: %v", k, err)
+		}
+		r[k] = n
+	}
+
+	for k, v := range prop {
+		if _, ok := rel[k]; ok {
+			continue
+		}
+		if _, ok := r[k]; !ok {
+			// The default of a member that isn't there is left as it is.
+			continue
+		}
+		n, err := proportional(r[k], v)
+		if err != nil {
+			return fmt.Errorf("proportional %s: %v", k, err)
+		}
+		r[k] = n
+	}
+
+	if m, ok := child["extend"].(map[string]interface{}); ok {
+		for k, v := range m {
+			r[k] = extend(r[k], v)
+		}
+	}
+
+	if m, ok := child["delete"].(map[string]interface{}); ok {
+		for k, v := range m {
+			if n, ok := remove(r[k], v); ok {
+				r[k] = n
+			}
+		}
+	}
+
+	return nil
+}
+
+// stripModifiers drops modifier blocks from a member that replaces its parent
+// outright, since there is nothing left for them to apply to.
+func stripModifiers(v interface{}) interface{} {
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return v
+	}
+	hasModifier := false
+	for _, k := range modifiers {
+		if _, ok := m[k]; ok {
+			hasModifier = true
+		}
+	}
+	if !hasModifier {
+		return v
+	}
+	s := make(map[string]interface{}, len(m))
+	for k, e := range m {
+		if !isModifier(k) {
+			s[k] = e
+		}
+	}
+	return s
+}
+
+func relative(base, by interface{}) (interface{}, error) {
+	switch b := by.(type) {
+	case float64:
+		switch v := base.(type) {
+		case nil:
+			return b, nil
+		case float64:
+			return v + b, nil
+		case string:
+			return relativeBare(v, b)
+		}
+	case string:
+		return relativeQuantity(base, b)
+	case map[string]interface{}:
+		switch v := base.(type) {
+		case nil:
+			return DeepCopy(b), nil
+		case map[string]interface{}:
+			return relativeObject(v, b)
+		case []interface{}:
+			return relativeDamage(v, b)
+		}
+	}
+	return nil, fmt.Errorf("cannot add %v to %v", by, base)
+}
+
+func relativeObject(base, by map[string]interface{}) (interface{}, error) {
+	r := DeepCopy(base).(map[string]interface{})
+	for k, v := range by {
+		if k == "damage_type" {
+			continue
+		}
+		n, err := relative(r[k], v)
+		if err != nil {
+			return nil, err
+		}
+		r[k] = n
+	}
+	return r, nil
+}
+
+// relativeDamage adds to the entry of a damage instance list with the same
+// damage type.
+func relativeDamage(base []interface{}, by map[string]interface{}) (interface{}, error) {
+	r := DeepCopy(base).([]interface{})
+	for i, e := range r {
+		m, ok := e.(map[string]interface{})
+		if !ok || m["damage_type"] != by["damage_type"] {
+			continue
+		}
+		n, err := relativeObject(m, by)
+		if err != nil {
+			return nil, err
+		}
+		r[i] = n
+		return r, nil
+	}
+	return append(r, DeepCopy(by)), nil
+}
+
+func proportional(base, by interface{}) (interface{}, error) {
+	switch b := by.(type) {
+	case float64:
+		if b <= 0 || b == 1 {
+			return nil, fmt.Errorf("multiplier must be positive and not 1, got %v", b)
+		}
+		switch v := base.(type) {
+		case float64:
+			return scale(v, b), nil
+		case string:
+			return proportionalQuantity(v, b)
+		}
+	case map[string]interface{}:
+		switch v := base.(type) {
+		case map[string]interface{}:
+			r := DeepCopy(v).(map[string]interface{})
+			for k, e := range b {
+				if k == "damage_type" {
+					continue
+				}
+				if _, ok := r[k]; !ok {
+					continue
+				}
+				n, err := proportional(r[k], e)
+				if err != nil {
+					return nil, err
+				}
+				r[k] = n
+			}
+			return r, nil
+		case []interface{}:
+			r := DeepCopy(v).([]interface{})
+			for i, e := range r {
+				m, ok := e.(map[string]interface{})
+				if !ok || m["damage_type"] != b["damage_type"] {
+					continue
+				}
+				n, err := proportional(m, b)
+				if err != nil {
+					return nil, err
+				}
+				r[i] = n
+			}
+			return r, nil
+		}
+	}
+	return nil, fmt.Errorf("cannot scale %v by %v", base, by)
+}
+
+// scale multiplies a number. Whole numbers stay whole because the game
+// stores most of these members as integers and truncates the product.
+func scale(v, by float64) float64 {
+	if v == math.Trunc(v) {
+		return math.Trunc(v * by)
+	}
+	return v * by
+}
+
+func extend(base, by interface{}) interface{} {
+	add, ok := by.([]interface{})
+	if !ok {
+		add = []interface{}{by}
+	}
+	list, _ := base.([]interface{})
+	list = DeepCopy(list).([]interface{})
+	for _, a := range add {
+		if !contains(list, a) {
+			list = append(list, DeepCopy(a))
+		}
+	}
+	return list
+}
+
+func remove(base, by interface{}) (interface{}, bool) {
+	list, ok := base.([]interface{})
+	if !ok {
+		return base, false
+	}
+	del, ok := by.([]interface{})
+	if !ok {
+		del = []interface{}{by}
+	}
+	r := make([]interface{}, 0, len(list))
+	for _, e := range list {
+		if !contains(del, e) {
+			r = append(r, e)
+		}
+	}
+	return r, true
+}
+
+func contains(list []interface{}, v interface{}) bool {
+	for _, e := range list {
+		if reflect.DeepEqual(e, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// DeepCopy copies decoded JSON so the result shares no maps or slices with v.
+func DeepCopy(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(t))
+		for k, e := range t {
+			m[k] = DeepCopy(e)
+		}
+		return m
+	case []interface{}:
+		s := make([]interface{}, len(t))
+		for i, e := range t {
+			s[i] = DeepCopy(e)
+		}
+		return s
+	default:
+		return v
+	}
+}
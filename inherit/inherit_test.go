@@ -0,0 +1,374 @@
+package inherit
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func decode(t *testing.T, s string) map[string]interface{} {
+	var m map[string]interface{}
+	if err := json.Unmarshal([]byte(s), &m); err != nil {
+		t.Fatalf("bad fixture %s: %v", s, err)
+	}
+	return m
+}
+
+// Definitions trimmed from the game's data/json, shared by the cases below.
+const (
+	ammo9mm = `{
+		"id": "9mm",
+		"type": "AMMO",
+		"name": "9x19mm JHP",
+		"description": "9x19mm ammunition with a brass jacketed 116gr hollow point bullet.",
+		"weight": 8,
+		"volume": 1,
+		"price": 2800,
+		"material": ["brass", "lead", "powder"],
+		"symbol": "=",
+		"color": "light_gray",
+		"count": 50,
+		"stack_size": 50,
+		"ammo_type": "9mm",
+		"casing": "9mm_casing",
+		"range": 14,
+		"damage": 24,
+		"pierce": 2,
+		"dispersion": 140,
+		"recoil": 450,
+		"effects": ["COOKOFF", "NEVER_MISFIRES"]
+	}`
+	rock = `{
+		"id": "rock",
+		"type": "GENERIC",
+		"category": "spare_parts",
+		"name": "rock",
+		"description": "A rock the size of a baseball.  Makes a decent melee weapon, and is also good for throwing at enemies.",
+		"weight": 657,
+		"volume": 1,
+		"price": 0,
+		"bashing": 7,
+		"to_hit": -2,
+		"material": ["stone"],
+		"symbol": "*",
+		"color": "light_gray"
+	}`
+	backpack = `{
+		"id": "backpack",
+		"type": "ARMOR",
+		"name": "backpack",
+		"weight": "633 g",
+		"volume": "2500 ml",
+		"price": 3900,
+		"material": ["cotton", "plastic"],
+		"covers": ["TORSO"],
+		"coverage": 30,
+		"encumbrance": 2,
+		"max_encumbrance": 15,
+		"storage": "15 L",
+		"flags": ["BELTED", "WATER_FRIENDLY"]
+	}`
+)
+
+func TestMerge(t *testing.T) {
+	cases := []struct {
+		name   string
+		base   string
+		child  string
+		nested map[string]bool
+		// want are the members checked, absent those that mustn't be there.
+		want   string
+		absent []string
+		err    bool
+	}{
+		{
+			name:  "replace",
+			base:  rock,
+			child: `{"id": "sharp_rock", "copy-from": "rock", "type": "GENERIC", "name": "sharp rock", "cutting": 5, "weight": 400}`,
+			want:  `{"id": "sharp_rock", "name": "sharp rock", "weight": 400, "cutting": 5, "bashing": 7, "material": ["stone"]}`,
+		},
+		{
+			name:  "relative number",
+			base:  ammo9mm,
+			child: `{"id": "9mmfmj", "copy-from": "9mm", "type": "AMMO", "name": "9x19mm FMJ", "relative": {"damage": -2, "pierce": 4}}`,
+			want:  `{"damage": 22, "pierce": 6, "price": 2800}`,
+		},
+		{
+			name:  "relative member the parent doesn't have",
+			base:  rock,
+			child: `{"id": "rock_heavy", "copy-from": "rock", "type": "GENERIC", "relative": {"cutting": 2}}`,
+			want:  `{"cutting": 2}`,
+		},
+		{
+			name:  "relative wins over the member",
+			base:  rock,
+			child: `{"id": "rock", "copy-from": "rock", "type": "GENERIC", "weight": 100, "relative": {"weight": 10}}`,
+			want:  `{"weight": 667}`,
+		},
+		{
+			name:  "relative mass",
+			base:  backpack,
+			child: `{"id": "backpack_hiking", "copy-from": "backpack", "type": "ARMOR", "relative": {"weight": "1 kg", "storage": "10 L"}}`,
+			want:  `{"weight": "1633 g", "storage": "25000 ml"}`,
+		},
+		{
+			name:  "relative volume over a bare volume",
+			base:  ammo9mm,
+			child: `{"id": "9mm_box", "copy-from": "9mm", "type": "AMMO", "relative": {"volume": "250 ml"}}`,
+			want:  `{"volume": "500 ml"}`,
+		},
+		{
+			name:  "relative bare number over a volume",
+			base:  backpack,
+			child: `{"id": "backpack_big", "copy-from": "backpack", "type": "ARMOR", "relative": {"volume": 2}}`,
+			want:  `{"volume": "3000 ml"}`,
+		},
+		{
+			name:  "relative mismatched kinds",
+			base:  backpack,
+			child: `{"id": "backpack_bad", "copy-from": "backpack", "type": "ARMOR", "relative": {"volume": "1 kg"}}`,
+			err:   true,
+		},
+		{
+			name:  "relative damage instance",
+			base:  `{"id": "knife_hunting", "type": "GENERIC", "name": "hunting knife", "melee_damage": [{"damage_type": "cut", "amount": 18}, {"damage_type": "bash", "amount": 2}]}`,
+			child: `{"id": "knife_hunting_sharp", "copy-from": "knife_hunting", "type": "GENERIC", "relative": {"melee_damage": {"damage_type": "cut", "amount": 4}}}`,
+			want:  `{"melee_damage": [{"damage_type": "cut", "amount": 22}, {"damage_type": "bash", "amount": 2}]}`,
+		},
+		{
+			name:  "proportional with extend and delete",
+			base:  ammo9mm,
+			child: `{"id": "reloaded_9mm", "copy-from": "9mm", "type": "AMMO", "name": "reloaded 9x19mm JHP", "proportional": {"price": 0.7, "damage": 0.9, "dispersion": 1.1}, "extend": {"effects": ["RECYCLED"]}, "delete": {"effects": ["NEVER_MISFIRES"]}}`,
+			want:  `{"price": 1959, "damage": 21, "dispersion": 154, "effects": ["COOKOFF", "RECYCLED"]}`,
+		},
+		{
+			name:  "proportional wins over the member",
+			base:  ammo9mm,
+			child: `{"id": "reloaded_9mm", "copy-from": "9mm", "type": "AMMO", "price": 1000, "proportional": {"price": 0.7}}`,
+			want:  `{"price": 1959}`,
+		},
+		{
+			name:  "relative wins over proportional",
+			base:  ammo9mm,
+			child: `{"id": "9mm_odd", "copy-from": "9mm", "type": "AMMO", "relative": {"damage": 1}, "proportional": {"damage": 2}}`,
+			want:  `{"damage": 25}`,
+		},
+		{
+			name:  "proportional quantity",
+			base:  backpack,
+			child: `{"id": "backpack_small", "copy-from": "backpack", "type": "ARMOR", "proportional": {"weight": 0.5, "storage": 0.5}}`,
+			want:  `{"weight": "316 g", "storage": "7500 ml"}`,
+		},
+		{
+			name:   "proportional member the parent doesn't have keeps the default",
+			base:   rock,
+			child:  `{"id": "rock_small", "copy-from": "rock", "type": "GENERIC", "cutting": 3, "proportional": {"cutting": 2, "weight": 0.5}}`,
+			want:   `{"weight": 328}`,
+			absent: []string{"cutting"},
+		},
+		{
+			name:  "proportional by 1",
+			base:  ammo9mm,
+			child: `{"id": "9mm_same", "copy-from": "9mm", "type": "AMMO", "proportional": {"price": 1}}`,
+			err:   true,
+		},
+		{
+			name:  "proportional by 0",
+			base:  ammo9mm,
+			child: `{"id": "9mm_free", "copy-from": "9mm", "type": "AMMO", "proportional": {"price": 0}}`,
+			err:   true,
+		},
+		{
+			name:   "extend and delete a list",
+			base:   backpack,
+			child:  `{"id": "backpack_waterproof", "copy-from": "backpack", "type": "ARMOR", "extend": {"flags": ["WATERPROOF", "BELTED"], "qualities": [["CONTAIN", 1]]}, "delete": {"flags": "WATER_FRIENDLY", "techniques": ["WBLOCK_1"]}}`,
+			want:   `{"flags": ["BELTED", "WATERPROOF"], "qualities": [["CONTAIN", 1]]}`,
+			absent: []string{"techniques"},
+		},
+		{
+			name:   "nested member",
+			base:   `{"id": "tshirt", "type": "TOOL_ARMOR", "armor_data": {"covers": ["TORSO"], "coverage": 90, "encumbrance": 5}}`,
+			child:  `{"id": "tshirt_tight", "copy-from": "tshirt", "type": "TOOL_ARMOR", "armor_data": {"encumbrance": 3, "relative": {"coverage": -10}}}`,
+			nested: map[string]bool{"armor_data": true},
+			want:   `{"armor_data": {"covers": ["TORSO"], "coverage": 80, "encumbrance": 3}}`,
+		},
+		{
+			name:  "member not nested is replaced",
+			base:  `{"id": "tshirt", "type": "TOOL_ARMOR", "armor_data": {"covers": ["TORSO"], "coverage": 90, "encumbrance": 5}}`,
+			child: `{"id": "tshirt_tight", "copy-from": "tshirt", "type": "TOOL_ARMOR", "armor_data": {"encumbrance": 3, "relative": {"coverage": -10}}}`,
+			want:  `{"armor_data": {"encumbrance": 3}}`,
+		},
+		{
+			name:   "abstract and modifiers not inherited",
+			base:   `{"abstract": "pistol_base", "type": "GUN", "skill": "pistol", "relative": {"weight": 1}, "flags": ["RELOAD_EJECT"]}`,
+			child:  `{"id": "glock_19", "copy-from": "pistol_base", "type": "GUN", "weight": 600}`,
+			want:   `{"id": "glock_19", "skill": "pistol", "weight": 600}`,
+			absent: []string{"abstract", "relative", "copy-from"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := Merge(decode(t, c.base), decode(t, c.child), c.nested)
+			if c.err {
+				if err == nil {
+					t.Fatalf("expected an error, got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			for k, want := range decode(t, c.want) {
+				if !reflect.DeepEqual(got[k], want) {
+					t.Errorf("%s: got %v, want %v", k, got[k], want)
+				}
+			}
+			for _, k := range c.absent {
+				if v, ok := got[k]; ok {
+					t.Errorf("%s: got %v, want nothing", k, v)
+				}
+			}
+		})
+	}
+}
+
+func TestResolve(t *testing.T) {
+	type result struct {
+		// want are the members checked.
+		want     string
+		ancestry []string
+		missing  string
+	}
+	cases := []struct {
+		name string
+		defs []string
+		want []result
+	}{
+		{
+			name: "chain",
+			defs: []string{
+				`{"abstract": "pistol_base", "type": "GUN", "skill": "pistol", "reload_noise_volume": 8, "flags": ["RELOAD_EJECT"]}`,
+				`{"id": "glock_19", "copy-from": "pistol_base", "type": "GUN", "name": "Glock 19", "weight": 600, "ammo": "9mm", "dispersion": 480, "clip_size": 15}`,
+				`{"id": "glock_17", "copy-from": "glock_19", "type": "GUN", "name": "Glock 17", "relative": {"weight": 25, "dispersion": -20}, "extend": {"flags": ["NEVER_JAMS"]}}`,
+			},
+			want: []result{
+				{want: `{"abstract": "pistol_base", "skill": "pistol"}`, ancestry: []string{}},
+				{want: `{"id": "glock_19", "skill": "pistol", "weight": 600}`, ancestry: []string{"pistol_base"}},
+				{want: `{"id": "glock_17", "name": "Glock 17", "skill": "pistol", "weight": 625, "dispersion": 460, "clip_size": 15, "flags": ["RELOAD_EJECT", "NEVER_JAMS"]}`, ancestry: []string{"glock_19", "pistol_base"}},
+			},
+		},
+		{
+			name: "parent defined later",
+			defs: []string{
+				`{"id": "reloaded_9mm", "copy-from": "9mm", "type": "AMMO", "proportional": {"price": 0.7}}`,
+				ammo9mm,
+			},
+			want: []result{
+				{want: `{"id": "reloaded_9mm", "price": 1959, "damage": 24}`, ancestry: []string{"9mm"}},
+				{want: `{"id": "9mm", "price": 2800}`, ancestry: []string{}},
+			},
+		},
+		{
+			name: "chain resolved in reverse",
+			defs: []string{
+				`{"id": "glock_17", "copy-from": "glock_19", "type": "GUN", "relative": {"weight": 25}}`,
+				`{"id": "glock_19", "copy-from": "pistol_base", "type": "GUN", "weight": 600}`,
+				`{"abstract": "pistol_base", "type": "GUN", "skill": "pistol"}`,
+			},
+			want: []result{
+				{want: `{"id": "glock_17", "skill": "pistol", "weight": 625}`, ancestry: []string{"glock_19", "pistol_base"}},
+				{want: `{"id": "glock_19", "skill": "pistol", "weight": 600}`, ancestry: []string{"pistol_base"}},
+				{want: `{"abstract": "pistol_base"}`, ancestry: []string{}},
+			},
+		},
+		{
+			name: "copy from an id to modify it in place",
+			defs: []string{
+				rock,
+				`{"id": "rock", "copy-from": "rock", "type": "GENERIC", "proportional": {"weight": 2}}`,
+				`{"id": "sharp_rock", "copy-from": "rock", "type": "GENERIC"}`,
+			},
+			want: []result{
+				{want: `{"id": "rock", "weight": 657}`, ancestry: []string{}},
+				{want: `{"id": "rock", "weight": 1314}`, ancestry: []string{"rock"}},
+				{want: `{"id": "sharp_rock", "weight": 1314}`, ancestry: []string{"rock", "rock"}},
+			},
+		},
+		{
+			name: "missing parent",
+			defs: []string{
+				`{"id": "glock_19", "copy-from": "pistol_base", "type": "GUN"}`,
+				`{"id": "glock_17", "copy-from": "glock_19", "type": "GUN"}`,
+				rock,
+			},
+			want: []result{
+				{missing: "pistol_base"},
+				{missing: "glock_19"},
+				{want: `{"id": "rock", "weight": 657}`, ancestry: []string{}},
+			},
+		},
+		{
+			name: "cycle",
+			defs: []string{
+				`{"id": "glock_19", "copy-from": "glock_17", "type": "GUN"}`,
+				`{"id": "glock_17", "copy-from": "glock_19", "type": "GUN"}`,
+				`{"id": "m1911", "copy-from": "m1911_mod", "type": "GUN"}`,
+			},
+			want: []result{
+				{missing: "glock_17"},
+				{missing: "glock_19"},
+				{missing: "m1911_mod"},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			defs := []*Definition{}
+			for _, s := range c.defs {
+				raw := decode(t, s)
+				key, _ := raw["id"].(string)
+				if a, ok := raw["abstract"].(string); ok {
+					key = a
+				}
+				defs = append(defs, &Definition{Namespace: "item", Key: key, Raw: raw})
+			}
+
+			finished := 0
+			r := &Resolver{Finish: func(d *Definition) { finished++ }}
+			r.Resolve(defs)
+
+			resolved := 0
+			for i, d := range defs {
+				w := c.want[i]
+				if w.missing != "" {
+					m, ok := d.Err.(*MissingParentError)
+					if !ok || m.Parent != w.missing {
+						t.Errorf("%d: got error %v, want missing parent %s", i, d.Err, w.missing)
+					}
+					continue
+				}
+				resolved++
+				if d.Err != nil {
+					t.Errorf("%d: %v", i, d.Err)
+					continue
+				}
+				for k, want := range decode(t, w.want) {
+					if !reflect.DeepEqual(d.Resolved[k], want) {
+						t.Errorf("%d: %s: got %v, want %v", i, k, d.Resolved[k], want)
+					}
+				}
+				if _, ok := d.Resolved["copy-from"]; ok {
+					t.Errorf("%d: copy-from left in the resolved definition", i)
+				}
+				if !reflect.DeepEqual(d.Ancestry, w.ancestry) {
+					t.Errorf("%d: got ancestry %v, want %v", i, d.Ancestry, w.ancestry)
+				}
+			}
+			if finished != resolved {
+				t.Errorf("finished %d definitions, want %d", finished, resolved)
+			}
+		})
+	}
+}
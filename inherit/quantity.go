@@ -0,0 +1,68 @@
+package inherit
+
+import (
+	"fmt"
+
+	"github.com/ralreegorganon/cddadb/units"
+)
+
+// bareFactors converts a bare number into the canonical unit of its kind, so
+// a quantity written with units can be added to one written without.
+var bareFactors = map[units.Kind]float64{
+	units.KindMass:     1,
+	units.KindVolume:   units.LegacyVolumeFactor,
+	units.KindEnergy:   1,
+	units.KindDuration: float64(units.Turns),
+}
+
+func canonicalValue(kind units.Kind, v interface{}) (float64, error) {
+	switch t := v.(type) {
+	case float64:
+		return t * bareFactors[kind], nil
+	case string:
+		k, f, err := units.Parse(t)
+		if err != nil {
+			return 0, err
+		}
+		if k != kind {
+			return 0, fmt.Errorf("cannot combine a %s with a %s", k, kind)
+		}
+		return f, nil
+	}
+	return 0, fmt.Errorf("not a %s: %v", kind, v)
+}
+
+// relativeQuantity adds a quantity written with units, like "10 g", to an
+// inherited value written either way.
+func relativeQuantity(base interface{}, by string) (interface{}, error) {
+	kind, b, err := units.Parse(by)
+	if err != nil {
+		return nil, err
+	}
+	if base == nil {
+		return by, nil
+	}
+	v, err := canonicalValue(kind, base)
+	if err != nil {
+		return nil, err
+	}
+	return units.Format(kind, v+b), nil
+}
+
+// relativeBare adds a bare number to an inherited quantity written with
+// units, reading the number in the bare unit of the same kind.
+func relativeBare(base string, by float64) (interface{}, error) {
+	kind, v, err := units.Parse(base)
+	if err != nil {
+		return nil, err
+	}
+	return units.Format(kind, v+by*bareFactors[kind]), nil
+}
+
+func proportionalQuantity(base string, by float64) (interface{}, error) {
+	kind, v, err := units.Parse(base)
+	if err != nil {
+		return nil, err
+	}
+	return units.Format(kind, scale(v, by)), nil
+}
@@ -0,0 +1,109 @@
+package inherit
+
+import "fmt"
+
+// Definition is one decoded JSON object waiting to be resolved.
+type Definition struct {
+	// Namespace is the lookup table copy-from searches, every item type
+	// shares one for example.
+	Namespace string
+	// Key is the id or abstract other definitions copy from, empty when the
+	// definition can't be copied.
+	Key string
+	// Raw is the definition as written.
+	Raw map[string]interface{}
+	// Defaults is the starting point when the definition copies from nothing.
+	Defaults map[string]interface{}
+
+	// Resolved is filled in by Resolve.
+	Resolved map[string]interface{}
+	// Ancestry is filled in by Resolve with the keys of the copy-from
+	// chain, nearest parent first.
+	Ancestry []string
+	// Err is set by Resolve when the definition couldn't be resolved.
+	Err error
+}
+
+// CopyFrom returns the key of the parent, empty for a new definition.
+func (d *Definition) CopyFrom() string {
+	s, _ := d.Raw["copy-from"].(string)
+	return s
+}
+
+// MissingParentError is reported for a definition whose copy-from parent
+// never turned up.
+type MissingParentError struct {
+	Parent string
+}
+
+func (e *MissingParentError) Error() string {
+	return fmt.Sprintf("copy-from parent %s not found", e.Parent)
+}
+
+// Resolver applies copy-from inheritance to definitions in load order.
+type Resolver struct {
+	// Nested lists, per namespace, the members merged one level down.
+	Nested map[string]map[string]bool
+	// Finish, when set, is called on each definition as soon as it has been
+	// resolved and before anything can copy from it.
+	Finish func(d *Definition)
+}
+
+// Resolve fills in Resolved or Err for every definition.
+//
+// Definitions are handled in order and a child copies whatever its parent
+// looks like at that point, so a mod can copy-from an id to modify it in
+// place. A child whose parent hasn't been seen yet is deferred and retried
+// once everything else has been, the same way the game does. Anything still
+// waiting when no more progress can be made gets a MissingParentError.
+func (r *Resolver) Resolve(defs []*Definition) {
+	templates := make(map[string]map[string]*Definition)
+
+	pending := defs
+	for len(pending) > 0 {
+		deferred := []*Definition{}
+		for _, d := range pending {
+			if templates[d.Namespace] == nil {
+				templates[d.Namespace] = make(map[string]*Definition)
+			}
+
+			base := d.Defaults
+			ancestry := []string{}
+			if parent := d.CopyFrom(); parent != "" {
+				t, ok := templates[d.Namespace][parent]
+				if !ok {
+					deferred = append(deferred, d)
+					continue
+				}
+				base = t.Resolved
+				ancestry = append(append(ancestry, parent), t.Ancestry...)
+			}
+			if base == nil {
+				base = map[string]interface{}{}
+			}
+
+			resolved, err := Merge(base, d.Raw, r.Nested[d.Namespace])
+			if err != nil {
+				d.Err = err
+				continue
+			}
+			d.Resolved = resolved
+			d.Ancestry = ancestry
+
+			if r.Finish != nil {
+				r.Finish(d)
+			}
+			if d.Key != "" {
+				templates[d.Namespace][d.Key] = d
+			}
+		}
+
+		if len(deferred) == len(pending) {
+			for _, d := range deferred {
+				d.Err = &MissingParentError{Parent: d.CopyFrom()}
+			}
+			return
+		}
+		pending = deferred
+	}
+}
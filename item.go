@@ -1,7 +1,30 @@
 package cddadb
 
+import "github.com/lib/pq"
+
 type Item struct {
 	ID       string `json:"id" db:"id"`
 	Abstract string `json:"abstract" db:"abstract"`
 	Type     string `json:"type" db:"type"`
+	Mod      string `json:"mod" db:"mod"`
+	Name     string `json:"name" db:"name"`
+	Weight   *int64 `json:"weight" db:"weight"`
+	Volume   *int64 `json:"volume" db:"volume"`
+	SpoilsIn *int64 `json:"spoils_in" db:"spoils_in"`
+}
+
+// ItemDetail is everything known about one item: its definition as written
+// and as resolved, where it came from and what it copies from.
+type ItemDetail struct {
+	ID       string         `json:"id" db:"id"`
+	Abstract string         `json:"abstract" db:"abstract"`
+	Type     string         `json:"type" db:"type"`
+	Source   string         `json:"source" db:"source"`
+	Mod      string         `json:"mod" db:"mod"`
+	Ancestry pq.StringArray `json:"ancestry" db:"ancestry"`
+	Raw      JSON           `json:"raw" db:"raw"`
+	Resolved JSON           `json:"resolved" db:"resolved"`
+	// Translation is the item's name and description in the language the
+	// request asked for, when it has been loaded.
+	Translation *Localized `json:"translation,omitempty" db:"-"`
 }
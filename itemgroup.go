@@ -0,0 +1,456 @@
+package cddadb
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// ItemGroup is a loot table. A distribution spawns one of its entries,
+// picked by weight, and a collection rolls each entry on its own with its
+// prob as a percentage. Old style groups without a subtype are
+// distributions.
+type ItemGroup struct {
+	ID            string
+	Subtype       string
+	ContainerItem string
+	Entries       []*ItemGroupEntry
+}
+
+// ItemGroupEntry is an item, a named group or an inline group, spawned Count
+// times. Count is picked evenly between CountMin and CountMax.
+type ItemGroupEntry struct {
+	Item          string
+	Group         string
+	Inline        *ItemGroup
+	Prob          float64
+	CountMin      int
+	CountMax      int
+	ContainerItem string
+}
+
+// ItemGroupExpansion is what an item group can spawn.
+type ItemGroupExpansion struct {
+	ID             string   `json:"id"`
+	Subtype        string   `json:"subtype"`
+	SpawnsAnything float64  `json:"spawns_anything"`
+	Items          []*Spawn `json:"items"`
+}
+
+// Spawn is how likely a group is to spawn at least one of an item, and how
+// many it spawns on average.
+type Spawn struct {
+	Item          string  `json:"item"`
+	Group         string  `json:"group,omitempty"`
+	Probability   float64 `json:"probability"`
+	ExpectedCount float64 `json:"expected_count"`
+}
+
+type itemGroupJSON struct {
+	ID            string            `json:"id"`
+	Subtype       string            `json:"subtype"`
+	ContainerItem string            `json:"container-item"`
+	Items         []json.RawMessage `json:"items"`
+	Groups        []json.RawMessage `json:"groups"`
+	Entries       []json.RawMessage `json:"entries"`
+}
+
+type itemGroupEntryJSON struct {
+	Item          string            `json:"item"`
+	Group         string            `json:"group"`
+	Distribution  []json.RawMessage `json:"distribution"`
+	Collection    []json.RawMessage `json:"collection"`
+	Prob          *float64          `json:"prob"`
+	Count         json.RawMessage   `json:"count"`
+	CountMin      *int              `json:"count-min"`
+	CountMax      *int              `json:"count-max"`
+	ContainerItem string            `json:"container-item"`
+}
+
+// ParseItemGroup reads a resolved item_group definition.
+func ParseItemGroup(resolved []byte) (*ItemGroup, error) {
+	var j itemGroupJSON
+	if err := json.Unmarshal(resolved, &j); err != nil {
+		return nil, err
+	}
+
+	g := &ItemGroup{ID: j.ID, Subtype: j.Subtype, ContainerItem: j.ContainerItem}
+	if g.Subtype != "collection" {
+		g.Subtype = "distribution"
+	}
+
+	for _, raw := range j.Items {
+		e, err := parseShortEntry(raw, false)
+		if err != nil {
+			return nil, fmt.Errorf("%s: items: %v", g.ID, err)
+		}
+		g.Entries = append(g.Entries, e)
+	}
+	for _, raw := range j.Groups {
+		e, err := parseShortEntry(raw, true)
+		if err != nil {
+			return nil, fmt.Errorf("%s: groups: %v", g.ID, err)
+		}
+		g.Entries = append(g.Entries, e)
+	}
+	for _, raw := range j.Entries {
+		e, err := parseEntry(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%s: entries: %v", g.ID, err)
+		}
+		g.Entries = append(g.Entries, e)
+	}
+
+	return g, nil
+}
+
+// parseShortEntry reads an entry of the items or groups lists, which is an
+// id, an [id, prob] pair or a full entry object.
+func parseShortEntry(raw json.RawMessage, group bool) (*ItemGroupEntry, error) {
+	e := &ItemGroupEntry{Prob: 100, CountMin: 1, CountMax: 1}
+
+	var id string
+	if err := json.Unmarshal(raw, &id); err == nil {
+		if group {
+			e.Group = id
+		} else {
+			e.Item = id
+		}
+		return e, nil
+	}
+
+	var pair []interface{}
+	if err := json.Unmarshal(raw, &pair); err == nil {
+		if len(pair) != 2 {
+			return nil, fmt.Errorf("expected [id, prob], got %s", raw)
+		}
+		id, _ := pair[0].(string)
+		prob, ok := pair[1].(float64)
+		if id == "" || !ok {
+			return nil, fmt.Errorf("expected [id, prob], got %s", raw)
+		}
+		if group {
+			e.Group = id
+		} else {
+			e.Item = id
+		}
+		e.Prob = prob
+		return e, nil
+	}
+
+	return parseEntry(raw)
+}
+
+func parseEntry(raw json.RawMessage) (*ItemGroupEntry, error) {
+	var j itemGroupEntryJSON
+	if err := json.Unmarshal(raw, &j); err != nil {
+		return nil, err
+	}
+
+	e := &ItemGroupEntry{
+		Item:          j.Item,
+		Group:         j.Group,
+		Prob:          100,
+		CountMin:      1,
+		CountMax:      1,
+		ContainerItem: j.ContainerItem,
+	}
+	if j.Prob != nil {
+		e.Prob = *j.Prob
+	}
+
+	switch {
+	case j.Distribution != nil:
+		inline, err := ParseItemGroup(mustJSON(map[string]interface{}{"subtype": "distribution", "entries": j.Distribution}))
+		if err != nil {
+			return nil, err
+		}
+		e.Inline = inline
+	case j.Collection != nil:
+		inline, err := ParseItemGroup(mustJSON(map[string]interface{}{"subtype": "collection", "entries": j.Collection}))
+		if err != nil {
+			return nil, err
+		}
+		e.Inline = inline
+	case e.Item == "" && e.Group == "":
+		return nil, fmt.Errorf("entry with no item or group: %s", raw)
+	}
+
+	if len(j.Count) > 0 {
+		var n int
+		var r []int
+		if err := json.Unmarshal(j.Count, &n); err == nil {
+			e.CountMin, e.CountMax = n, n
+		} else if err := json.Unmarshal(j.Count, &r); err == nil && len(r) == 2 {
+			e.CountMin, e.CountMax = r[0], r[1]
+		} else {
+			return nil, fmt.Errorf("malformed count %s", j.Count)
+		}
+	}
+	if j.CountMin != nil {
+		e.CountMin = *j.CountMin
+		if e.CountMax < e.CountMin {
+			e.CountMax = e.CountMin
+		}
+	}
+	if j.CountMax != nil {
+		e.CountMax = *j.CountMax
+	}
+
+	return e, nil
+}
+
+func mustJSON(v interface{}) []byte {
+	b, _ := json.Marshal(v)
+	return b
+}
+
+// Members lists the items and named groups the group can spawn directly,
+// looking inside inline groups. Containers count as items.
+func (g *ItemGroup) Members() (items, groups []string) {
+	if g.ContainerItem != "" {
+		items = append(items, g.ContainerItem)
+	}
+	for _, e := range g.Entries {
+		switch {
+		case e.Inline != nil:
+			i, gs := e.Inline.Members()
+			items = append(items, i...)
+			groups = append(groups, gs...)
+		case e.Group != "":
+			groups = append(groups, e.Group)
+		default:
+			items = append(items, e.Item)
+		}
+		if e.ContainerItem != "" {
+			items = append(items, e.ContainerItem)
+		}
+	}
+	return items, groups
+}
+
+// expansion is the chance of spawning at least one of each item, the
+// expected number of each, and the chance of spawning anything at all.
+// truncated marks one that left out a group because it contains itself, which
+// only holds for the group the expansion was started from.
+type expansion struct {
+	prob      map[string]float64
+	expected  map[string]float64
+	any       float64
+	truncated bool
+}
+
+func newExpansion() *expansion {
+	return &expansion{prob: make(map[string]float64), expected: make(map[string]float64)}
+}
+
+// ItemGroupExpander evaluates item groups, remembering the groups it has
+// already worked out.
+//
+// Entries are taken to roll independently of each other, which is how
+// collections and repeated rolls behave in the game, so the probabilities
+// are exact for a single distribution and close otherwise.
+type ItemGroupExpander struct {
+	groups map[string]*ItemGroup
+	done   map[string]*expansion
+}
+
+func NewItemGroupExpander(groups map[string]*ItemGroup) *ItemGroupExpander {
+	return &ItemGroupExpander{groups: groups, done: make(map[string]*expansion)}
+}
+
+// Expand works out what the group with the given id can spawn, nil if there
+// is no such group.
+func (x *ItemGroupExpander) Expand(id string) *ItemGroupExpansion {
+	g, ok := x.groups[id]
+	if !ok {
+		return nil
+	}
+	return expansionOf(id, g, x.named(id, map[string]bool{}))
+}
+
+// ExpandGroup works out what a group that isn't in the table, like one
+// written inline, can spawn.
+func (x *ItemGroupExpander) ExpandGroup(g *ItemGroup) *ItemGroupExpansion {
+	return expansionOf(g.ID, g, x.group(g, map[string]bool{}))
+}
+
+// ExpandInline works out what a member that names an item group, or writes
+// one inline as a group or a bare list of entries, can spawn. An inline group
+// without a subtype takes the given one, as the game does for the member.
+func (x *ItemGroupExpander) ExpandInline(raw json.RawMessage, id, subtype string) *ItemGroupExpansion {
+	if len(raw) == 0 {
+		return nil
+	}
+	var name string
+	if err := json.Unmarshal(raw, &name); err == nil {
+		return x.Expand(name)
+	}
+
+	var entries []json.RawMessage
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &entries); err == nil {
+		raw = mustJSON(map[string]interface{}{"subtype": subtype, "entries": entries})
+	} else if err := json.Unmarshal(raw, &members); err == nil {
+		if _, ok := members["subtype"]; !ok {
+			members["subtype"] = mustJSON(subtype)
+			raw = mustJSON(members)
+		}
+	} else {
+		return nil
+	}
+
+	g, err := ParseItemGroup(raw)
+	if err != nil {
+		return nil
+	}
+	g.ID = id
+	return x.ExpandGroup(g)
+}
+
+func expansionOf(id string, g *ItemGroup, e *expansion) *ItemGroupExpansion {
+	r := &ItemGroupExpansion{ID: id, Subtype: g.Subtype, SpawnsAnything: e.any, Items: []*Spawn{}}
+	for item, p := range e.prob {
+		r.Items = append(r.Items, &Spawn{Item: item, Probability: p, ExpectedCount: e.expected[item]})
+	}
+	sort.Slice(r.Items, func(i, j int) bool {
+		if r.Items[i].Probability == r.Items[j].Probability {
+			return r.Items[i].Item < r.Items[j].Item
+		}
+		return r.Items[i].Probability > r.Items[j].Probability
+	})
+	return r
+}
+
+// named expands a group by id. A group that contains itself, directly or not,
+// spawns nothing the second time round. Expansions cut short that way aren't
+// remembered, since expanding the group on its own would go one round deeper.
+func (x *ItemGroupExpander) named(id string, visiting map[string]bool) *expansion {
+	if e, ok := x.done[id]; ok {
+		return e
+	}
+	g, ok := x.groups[id]
+	if !ok {
+		return newExpansion()
+	}
+	if visiting[id] {
+		e := newExpansion()
+		e.truncated = true
+		return e
+	}
+	visiting[id] = true
+	e := x.group(g, visiting)
+	delete(visiting, id)
+	if !e.truncated {
+		x.done[id] = e
+	}
+	return e
+}
+
+func (x *ItemGroupExpander) group(g *ItemGroup, visiting map[string]bool) *expansion {
+	r := newExpansion()
+
+	total := 0.0
+	for _, e := range g.Entries {
+		total += e.Prob
+	}
+
+	// none is the chance, per item, that no entry spawns it, for collections.
+	none := make(map[string]float64)
+	noneAny := 1.0
+	for _, entry := range g.Entries {
+		var chance float64
+		if g.Subtype == "collection" {
+			chance = math.Min(entry.Prob/100, 1)
+		} else if total > 0 {
+			chance = entry.Prob / total
+		}
+		if chance <= 0 {
+			continue
+		}
+
+		e := x.entry(entry, visiting)
+		r.truncated = r.truncated || e.truncated
+		for item, p := range e.prob {
+			r.expected[item] += chance * e.expected[item]
+			if g.Subtype == "collection" {
+				if _, ok := none[item]; !ok {
+					none[item] = 1
+				}
+				none[item] *= 1 - chance*p
+			} else {
+				r.prob[item] += chance * p
+			}
+		}
+		if g.Subtype == "collection" {
+			noneAny *= 1 - chance*e.any
+		} else {
+			r.any += chance * e.any
+		}
+	}
+	if g.Subtype == "collection" {
+		for item, n := range none {
+			r.prob[item] = 1 - n
+		}
+		r.any = 1 - noneAny
+	}
+
+	if g.ContainerItem != "" && r.any > 0 {
+		r.prob[g.ContainerItem] = math.Max(r.prob[g.ContainerItem], r.any)
+		r.expected[g.ContainerItem] += r.any
+	}
+	return r
+}
+
+// entry expands one entry. An item entry spawns its count of the item in one
+// go while a group entry rolls the group count times.
+func (x *ItemGroupExpander) entry(entry *ItemGroupEntry, visiting map[string]bool) *expansion {
+	lo, hi := entry.CountMin, entry.CountMax
+	if lo < 0 {
+		lo = 0
+	}
+	if hi < lo {
+		hi = lo
+	}
+	mean := float64(lo+hi) / 2
+
+	r := newExpansion()
+	switch {
+	case entry.Inline != nil || entry.Group != "":
+		var once *expansion
+		if entry.Inline != nil {
+			once = x.group(entry.Inline, visiting)
+		} else {
+			once = x.named(entry.Group, visiting)
+		}
+		for item, p := range once.prob {
+			r.prob[item] = repeated(p, lo, hi)
+			r.expected[item] = mean * once.expected[item]
+		}
+		r.any = repeated(once.any, lo, hi)
+		r.truncated = once.truncated
+	case hi > 0:
+		// A count range starting at zero sometimes spawns none.
+		p := repeated(1, lo, hi)
+		r.prob[entry.Item] = p
+		r.expected[entry.Item] = mean
+		r.any = p
+	}
+
+	if entry.ContainerItem != "" && r.any > 0 {
+		r.prob[entry.ContainerItem] = math.Max(r.prob[entry.ContainerItem], r.any)
+		r.expected[entry.ContainerItem] += r.any
+	}
+	return r
+}
+
+// repeated is the chance of at least one success from between lo and hi
+// tries, each number of tries being equally likely.
+func repeated(p float64, lo, hi int) float64 {
+	total := 0.0
+	for n := lo; n <= hi; n++ {
+		total += 1 - math.Pow(1-p, float64(n))
+	}
+	return total / float64(hi-lo+1)
+}
@@ -0,0 +1,265 @@
+package cddadb
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/lib/pq"
+	"github.com/ralreegorganon/cddadb/units"
+)
+
+const (
+	defaultItemLimit = 100
+	maxItemLimit     = 1000
+)
+
+// ItemQuery filters, sorts and pages the item list.
+//
+// Types, mods, materials and categories match any of the values given, flags
+// have to all be present. Ranges compare either one of the measured columns,
+// in grams, millilitres and turns or as a quantity with units, or any number
+// at the top of the resolved definition.
+type ItemQuery struct {
+	Version    string
+	Types      []string
+	Mods       []string
+	Materials  []string
+	Flags      []string
+	Categories []string
+	Ranges     []Range
+	Sort       string
+	Descending bool
+	Cursor     *ItemCursor
+	Limit      int
+	// Lang is the language to give names in, empty for English. Sorting by
+	// name still orders by the English name.
+	Lang string
+}
+
+// Range bounds a numeric member, either end being optional.
+type Range struct {
+	Field string
+	Min   *float64
+	Max   *float64
+}
+
+// ItemCursor marks the last item of a page so the next one can carry on after
+// it.
+type ItemCursor struct {
+	Value interface{} `json:"v"`
+	Row   int64       `json:"r"`
+}
+
+// itemSorts maps the sort keys to the expressions they order by. Missing
+// numbers sort as -1 so paging over them stays stable.
+var itemSorts = map[string]string{
+	"id":        "coalesce(id, abstract)",
+	"type":      "type",
+	"mod":       "mod",
+	"name":      "coalesce(resolved#>>'{name,str}', resolved->>'name', '')",
+	"weight":    "coalesce(weight, -1)",
+	"volume":    "coalesce(volume, -1)",
+	"spoils_in": "coalesce(spoils_in, -1)",
+}
+
+// measuredColumns are the ranges answered from a column rather than the
+// resolved JSON, along with the kind of quantity they hold.
+var measuredColumns = map[string]units.Kind{
+	"weight":    units.KindMass,
+	"volume":    units.KindVolume,
+	"spoils_in": units.KindDuration,
+}
+
+var fieldName = regexp.MustCompile(`^[a-z0-9_]+$`)
+
+// ParseItemQuery reads an ItemQuery from the query string of a request.
+func ParseItemQuery(q url.Values) (*ItemQuery, error) {
+	iq := &ItemQuery{
+		Version:    q.Get("version"),
+		Types:      list(q["type"]),
+		Mods:       list(q["mod"]),
+		Materials:  list(q["material"]),
+		Flags:      list(q["flag"]),
+		Categories: list(q["category"]),
+		Sort:       "id",
+		Limit:      defaultItemLimit,
+	}
+
+	if s := q.Get("sort"); s != "" {
+		iq.Descending = strings.HasPrefix(s, "-")
+		iq.Sort = strings.TrimPrefix(s, "-")
+		if _, ok := itemSorts[iq.Sort]; !ok {
+			return nil, &BadRequestError{Message: fmt.Sprintf("can't sort by %s", iq.Sort)}
+		}
+	}
+
+	if s := q.Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 || n > maxItemLimit {
+			return nil, &BadRequestError{Message: fmt.Sprintf("limit must be between 1 and %d", maxItemLimit)}
+		}
+		iq.Limit = n
+	}
+
+	if s := q.Get("cursor"); s != "" {
+		c, err := decodeCursor(s)
+		if err != nil {
+			return nil, &BadRequestError{Message: "malformed cursor"}
+		}
+		iq.Cursor = c
+	}
+
+	ranges := make(map[string]*Range)
+	fields := []string{}
+	for k, vs := range q {
+		var bound string
+		switch {
+		case strings.HasPrefix(k, "min_"):
+			bound = "min"
+		case strings.HasPrefix(k, "max_"):
+			bound = "max"
+		default:
+			continue
+		}
+		field := k[4:]
+		if !fieldName.MatchString(field) {
+			return nil, &BadRequestError{Message: fmt.Sprintf("can't filter on %s", field)}
+		}
+		v, err := rangeValue(field, vs[0])
+		if err != nil {
+			return nil, &BadRequestError{Message: fmt.Sprintf("%s: %v", k, err)}
+		}
+		r, ok := ranges[field]
+		if !ok {
+			r = &Range{Field: field}
+			ranges[field] = r
+			fields = append(fields, field)
+		}
+		if bound == "min" {
+			r.Min = &v
+		} else {
+			r.Max = &v
+		}
+	}
+	for _, f := range fields {
+		iq.Ranges = append(iq.Ranges, *ranges[f])
+	}
+
+	return iq, nil
+}
+
+// list splits comma separated values and drops empty ones, so both
+// ?type=GUN&type=AMMO and ?type=GUN,AMMO work.
+func list(values []string) []string {
+	l := []string{}
+	for _, v := range values {
+		for _, s := range strings.Split(v, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				l = append(l, s)
+			}
+		}
+	}
+	return l
+}
+
+func rangeValue(field, s string) (float64, error) {
+	if v, err := strconv.ParseFloat(s, 64); err == nil {
+		return v, nil
+	}
+	kind, ok := measuredColumns[field]
+	if !ok {
+		return 0, fmt.Errorf("%q isn't a number", s)
+	}
+	k, v, err := units.Parse(s)
+	if err != nil {
+		return 0, err
+	}
+	if k != kind {
+		return 0, fmt.Errorf("expected a %s, got %q", kind, s)
+	}
+	return v, nil
+}
+
+func decodeCursor(s string) (*ItemCursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	c := &ItemCursor{}
+	if err := json.Unmarshal(b, c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Encode writes the cursor out for the next page's ?cursor=.
+func (c *ItemCursor) Encode() string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// where builds the conditions of the query, numbering its arguments from
+// after the ones already in args.
+func (iq *ItemQuery) where(args []interface{}) ([]string, []interface{}) {
+	conds := []string{}
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if len(iq.Types) > 0 {
+		conds = append(conds, "type = any("+arg(pq.Array(iq.Types))+")")
+	}
+	if len(iq.Mods) > 0 {
+		conds = append(conds, "mod = any("+arg(pq.Array(iq.Mods))+")")
+	}
+	if len(iq.Categories) > 0 {
+		conds = append(conds, "resolved->>'category' = any("+arg(pq.Array(iq.Categories))+")")
+	}
+	if len(iq.Materials) > 0 {
+		// ?| matches a lone string as well as an entry in a list.
+		conds = append(conds, "resolved->'material' ?| "+arg(pq.Array(iq.Materials)))
+	}
+	if len(iq.Flags) > 0 {
+		conds = append(conds, "resolved->'flags' ?& "+arg(pq.Array(iq.Flags)))
+	}
+
+	for _, r := range iq.Ranges {
+		expr := r.Field
+		if _, ok := measuredColumns[r.Field]; !ok {
+			f := arg(r.Field) + "::text"
+			expr = fmt.Sprintf("(case when jsonb_typeof(resolved->%s) = 'number' then (resolved->>%s)::numeric end)", f, f)
+		}
+		if r.Min != nil {
+			conds = append(conds, expr+" >= "+arg(*r.Min))
+		}
+		if r.Max != nil {
+			conds = append(conds, expr+" <= "+arg(*r.Max))
+		}
+	}
+
+	if iq.Cursor != nil {
+		op := ">"
+		if iq.Descending {
+			op = "<"
+		}
+		conds = append(conds, fmt.Sprintf("(%s, game_object_id) %s (%s, %s)", itemSorts[iq.Sort], op, arg(iq.Cursor.Value), arg(iq.Cursor.Row)))
+	}
+
+	return conds, args
+}
+
+// orderBy orders by the sort key, with the row breaking ties so the cursor
+// always lands in the same place.
+func (iq *ItemQuery) orderBy() string {
+	dir := "asc"
+	if iq.Descending {
+		dir = "desc"
+	}
+	return fmt.Sprintf("%s %s, game_object_id %s", itemSorts[iq.Sort], dir, dir)
+}
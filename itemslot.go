@@ -0,0 +1,231 @@
+package cddadb
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/ralreegorganon/cddadb/units"
+)
+
+// The slots below mirror the islot_* structures Item_factory fills in. Each
+// carries the members its load function reads.
+
+type ContainerSlot struct {
+	Contains    *units.Volume `json:"contains,omitempty"`
+	Seals       bool          `json:"seals,omitempty"`
+	Watertight  bool          `json:"watertight,omitempty"`
+	Preserves   bool          `json:"preserves,omitempty"`
+	UnsealsInto string        `json:"unseals_into,omitempty"`
+}
+
+type ToolSlot struct {
+	Ammo           Tags     `json:"ammo,omitempty"`
+	MaxCharges     int      `json:"max_charges,omitempty"`
+	InitialCharges int      `json:"initial_charges,omitempty"`
+	RandCharges    []int    `json:"rand_charges,omitempty"`
+	ChargesPerUse  int      `json:"charges_per_use,omitempty"`
+	TurnsPerCharge int      `json:"turns_per_charge,omitempty"`
+	PowerDraw      *Measure `json:"power_draw,omitempty"`
+	RevertTo       string   `json:"revert_to,omitempty"`
+	RevertMsg      string   `json:"revert_msg,omitempty"`
+	Sub            string   `json:"sub,omitempty"`
+}
+
+type ComestibleSlot struct {
+	ComestibleType     string     `json:"comestible_type,omitempty"`
+	Tool               string     `json:"tool,omitempty"`
+	Charges            int        `json:"charges,omitempty"`
+	Quench             int        `json:"quench,omitempty"`
+	Nutrition          int        `json:"nutrition,omitempty"`
+	Calories           *int       `json:"calories,omitempty"`
+	SpoilsIn           *SpoilTime `json:"spoils_in,omitempty"`
+	AddictionPotential int        `json:"addiction_potential,omitempty"`
+	AddictionType      string     `json:"addiction_type,omitempty"`
+	Fun                int        `json:"fun,omitempty"`
+	Stim               int        `json:"stim,omitempty"`
+	Healthy            int        `json:"healthy,omitempty"`
+	Parasites          int        `json:"parasites,omitempty"`
+	Radiation          int        `json:"radiation,omitempty"`
+	FreezingPoint      *int       `json:"freezing_point,omitempty"`
+	Vitamins           Levels     `json:"vitamins,omitempty"`
+	RotSpawn           string     `json:"rot_spawn,omitempty"`
+	RotSpawnChance     int        `json:"rot_spawn_chance,omitempty"`
+	CooksLike          string     `json:"cooks_like,omitempty"`
+	SmokingResult      string     `json:"smoking_result,omitempty"`
+}
+
+type BrewableSlot struct {
+	Results Tags            `json:"results,omitempty"`
+	Time    *units.Duration `json:"time,omitempty"`
+}
+
+type ArmorSlot struct {
+	Covers                  Tags          `json:"covers,omitempty"`
+	Sided                   bool          `json:"sided,omitempty"`
+	Coverage                int           `json:"coverage,omitempty"`
+	Encumbrance             int           `json:"encumbrance,omitempty"`
+	Thickness               int           `json:"material_thickness,omitempty"`
+	EnvironmentalProtection int           `json:"environmental_protection,omitempty"`
+	Storage                 *units.Volume `json:"storage,omitempty"`
+	Warmth                  int           `json:"warmth,omitempty"`
+	PowerArmor              bool          `json:"power_armor,omitempty"`
+}
+
+type BookSlot struct {
+	MaxLevel      int    `json:"max_level,omitempty"`
+	RequiredLevel int    `json:"required_level,omitempty"`
+	Fun           int    `json:"fun,omitempty"`
+	Intelligence  int    `json:"intelligence,omitempty"`
+	Time          int    `json:"time,omitempty"`
+	Skill         string `json:"skill,omitempty"`
+	Chapters      int    `json:"chapters,omitempty"`
+	MartialArt    string `json:"martial_art,omitempty"`
+}
+
+// ModSlot is shared by gunmods and toolmods, it's how either changes what
+// its host accepts.
+type ModSlot struct {
+	AmmoModifier       Tags          `json:"ammo_modifier,omitempty"`
+	CapacityMultiplier float64       `json:"capacity_multiplier,omitempty"`
+	AcceptableAmmo     Tags          `json:"acceptable_ammo,omitempty"`
+	MagazineAdaptor    AmmoMagazines `json:"magazine_adaptor,omitempty"`
+}
+
+type EngineSlot struct {
+	Displacement int `json:"displacement,omitempty"`
+}
+
+type WheelSlot struct {
+	Diameter int `json:"diameter,omitempty"`
+	Width    int `json:"width,omitempty"`
+}
+
+type FuelSlot struct {
+	Energy float64 `json:"energy,omitempty"`
+}
+
+// GunMode is one of a gun's firing modes, from [id, name, shots, [flags]].
+type GunMode struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Shots int    `json:"shots"`
+	Flags Tags   `json:"flags,omitempty"`
+}
+
+func (g *GunMode) UnmarshalJSON(b []byte) error {
+	var parts []json.RawMessage
+	if err := json.Unmarshal(b, &parts); err != nil {
+		return err
+	}
+	if len(parts) < 3 {
+		return fmt.Errorf("gun mode needs at least [id, name, shots]: %s", b)
+	}
+	if err := json.Unmarshal(parts[0], &g.ID); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(parts[1], &g.Name); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(parts[2], &g.Shots); err != nil {
+		return err
+	}
+	if len(parts) > 3 {
+		return json.Unmarshal(parts[3], &g.Flags)
+	}
+	return nil
+}
+
+type GunSlot struct {
+	Skill                string         `json:"skill,omitempty"`
+	Ammo                 Tags           `json:"ammo,omitempty"`
+	RangedDamage         DamageInstance `json:"ranged_damage,omitempty"`
+	Pierce               int            `json:"pierce,omitempty"`
+	Range                int            `json:"range,omitempty"`
+	Dispersion           int            `json:"dispersion,omitempty"`
+	SightDispersion      int            `json:"sight_dispersion,omitempty"`
+	Recoil               int            `json:"recoil,omitempty"`
+	Handling             int            `json:"handling,omitempty"`
+	Durability           int            `json:"durability,omitempty"`
+	Burst                int            `json:"burst,omitempty"`
+	Loudness             int            `json:"loudness,omitempty"`
+	ClipSize             int            `json:"clip_size,omitempty"`
+	Reload               int            `json:"reload,omitempty"`
+	ReloadNoise          string         `json:"reload_noise,omitempty"`
+	ReloadNoiseVolume    int            `json:"reload_noise_volume,omitempty"`
+	BarrelLength         *units.Volume  `json:"barrel_length,omitempty"`
+	UPSCharges           int            `json:"ups_charges,omitempty"`
+	ValidModLocations    Levels         `json:"valid_mod_locations,omitempty"`
+	Modes                []GunMode      `json:"modes,omitempty"`
+	BuiltInMods          Tags           `json:"built_in_mods,omitempty"`
+	DefaultMods          Tags           `json:"default_mods,omitempty"`
+	BlackpowderTolerance int            `json:"blackpowder_tolerance,omitempty"`
+	MinCycleRecoil       int            `json:"min_cycle_recoil,omitempty"`
+	AmmoEffects          Tags           `json:"ammo_effects,omitempty"`
+	AmmoToFire           int            `json:"ammo_to_fire,omitempty"`
+}
+
+type GunmodSlot struct {
+	Location           string         `json:"location,omitempty"`
+	ModTargets         Tags           `json:"mod_targets,omitempty"`
+	DispersionModifier int            `json:"dispersion_modifier,omitempty"`
+	SightDispersion    *int           `json:"sight_dispersion,omitempty"`
+	AimSpeed           *int           `json:"aim_speed,omitempty"`
+	DamageModifier     DamageInstance `json:"damage_modifier,omitempty"`
+	LoudnessModifier   int            `json:"loudness_modifier,omitempty"`
+	RangeModifier      int            `json:"range_modifier,omitempty"`
+	HandlingModifier   int            `json:"handling_modifier,omitempty"`
+	InstallTime        int            `json:"install_time,omitempty"`
+	ModeModifier       []GunMode      `json:"mode_modifier,omitempty"`
+	AddMod             Levels         `json:"add_mod,omitempty"`
+	ConsumeChance      int            `json:"consume_chance,omitempty"`
+	ConsumeDivisor     int            `json:"consume_divisor,omitempty"`
+	UPSCharges         int            `json:"ups_charges,omitempty"`
+	ReloadModifier     int            `json:"reload_modifier,omitempty"`
+	MinStrRequiredMod  int            `json:"min_str_required_mod,omitempty"`
+}
+
+type MagazineSlot struct {
+	AmmoType    Tags   `json:"ammo_type,omitempty"`
+	Capacity    int    `json:"capacity,omitempty"`
+	Count       int    `json:"count,omitempty"`
+	DefaultAmmo string `json:"default_ammo,omitempty"`
+	Reliability int    `json:"reliability,omitempty"`
+	ReloadTime  int    `json:"reload_time,omitempty"`
+	Linkage     string `json:"linkage,omitempty"`
+}
+
+type AmmoSlot struct {
+	AmmoType         Tags           `json:"ammo_type,omitempty"`
+	Casing           string         `json:"casing,omitempty"`
+	Drop             string         `json:"drop,omitempty"`
+	DropChance       float64        `json:"drop_chance,omitempty"`
+	DropActive       bool           `json:"drop_active,omitempty"`
+	Damage           DamageInstance `json:"damage,omitempty"`
+	Pierce           int            `json:"pierce,omitempty"`
+	Range            int            `json:"range,omitempty"`
+	Dispersion       int            `json:"dispersion,omitempty"`
+	Recoil           int            `json:"recoil,omitempty"`
+	Count            int            `json:"count,omitempty"`
+	StackSize        int            `json:"stack_size,omitempty"`
+	Loudness         *int           `json:"loudness,omitempty"`
+	Effects          Tags           `json:"effects,omitempty"`
+	PropDamage       *float64       `json:"prop_damage,omitempty"`
+	Cookoff          bool           `json:"cookoff,omitempty"`
+	SpecialCookoff   bool           `json:"special_cookoff,omitempty"`
+	ShowStats        bool           `json:"show_stats,omitempty"`
+	DontRecoverOneIn int            `json:"dont_recover_one_in,omitempty"`
+}
+
+type BionicSlot struct {
+	Difficulty int  `json:"difficulty,omitempty"`
+	IsUpgrade  bool `json:"is_upgrade,omitempty"`
+}
+
+type SeedSlot struct {
+	Grow       *GrowTime `json:"grow,omitempty"`
+	FruitDiv   int       `json:"fruit_div,omitempty"`
+	PlantName  string    `json:"plant_name,omitempty"`
+	Fruit      string    `json:"fruit,omitempty"`
+	Seeds      *bool     `json:"seeds,omitempty"`
+	Byproducts Tags      `json:"byproducts,omitempty"`
+}
@@ -0,0 +1,301 @@
+package cddadb
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/ralreegorganon/cddadb/units"
+)
+
+// ItemType is a resolved item definition, the equivalent of itype in the
+// game. Members every item has live on the type itself, the rest are in the
+// slots the item's type fills in.
+type ItemType struct {
+	ID                string          `json:"id"`
+	Type              string          `json:"type"`
+	Name              Translation     `json:"name"`
+	NamePlural        string          `json:"name_plural,omitempty"`
+	Description       string          `json:"description,omitempty"`
+	Symbol            string          `json:"symbol,omitempty"`
+	Color             string          `json:"color,omitempty"`
+	Category          string          `json:"category,omitempty"`
+	Weight            *units.Mass     `json:"weight,omitempty"`
+	Volume            *units.Volume   `json:"volume,omitempty"`
+	Price             *Measure        `json:"price,omitempty"`
+	PricePostapoc     *Measure        `json:"price_postapoc,omitempty"`
+	Stackable         bool            `json:"stackable,omitempty"`
+	IntegralVolume    *units.Volume   `json:"integral_volume,omitempty"`
+	Bashing           int             `json:"bashing,omitempty"`
+	Cutting           int             `json:"cutting,omitempty"`
+	ToHit             int             `json:"to_hit,omitempty"`
+	Container         string          `json:"container,omitempty"`
+	Rigid             *bool           `json:"rigid,omitempty"`
+	MinStrength       int             `json:"min_strength,omitempty"`
+	MinDexterity      int             `json:"min_dexterity,omitempty"`
+	MinIntelligence   int             `json:"min_intelligence,omitempty"`
+	MinPerception     int             `json:"min_perception,omitempty"`
+	Emits             Tags            `json:"emits,omitempty"`
+	MagazineWell      *units.Volume   `json:"magazine_well,omitempty"`
+	ExplodeInFire     bool            `json:"explode_in_fire,omitempty"`
+	ThrownDamage      DamageInstance  `json:"thrown_damage,omitempty"`
+	DamageStates      []int           `json:"damage_states,omitempty"`
+	Material          Tags            `json:"material,omitempty"`
+	Phase             string          `json:"phase,omitempty"`
+	Magazines         AmmoMagazines   `json:"magazines,omitempty"`
+	MinSkills         Levels          `json:"min_skills,omitempty"`
+	Explosion         json.RawMessage `json:"explosion,omitempty"`
+	Flags             Tags            `json:"flags,omitempty"`
+	Qualities         Levels          `json:"qualities,omitempty"`
+	Properties        Properties      `json:"properties,omitempty"`
+	Techniques        Tags            `json:"techniques,omitempty"`
+	UseAction         json.RawMessage `json:"use_action,omitempty"`
+	CountdownInterval int             `json:"countdown_interval,omitempty"`
+	CountdownDestroy  bool            `json:"countdown_destroy,omitempty"`
+	CountdownAction   json.RawMessage `json:"countdown_action,omitempty"`
+	DropAction        json.RawMessage `json:"drop_action,omitempty"`
+	SnippetCategory   json.RawMessage `json:"snippet_category,omitempty"`
+
+	ContainerSlot  *ContainerSlot  `json:"container_data,omitempty"`
+	ToolSlot       *ToolSlot       `json:"tool_data,omitempty"`
+	ComestibleSlot *ComestibleSlot `json:"comestible_data,omitempty"`
+	BrewableSlot   *BrewableSlot   `json:"brewable,omitempty"`
+	ArmorSlot      *ArmorSlot      `json:"armor_data,omitempty"`
+	BookSlot       *BookSlot       `json:"book_data,omitempty"`
+	ModSlot        *ModSlot        `json:"mod_data,omitempty"`
+	EngineSlot     *EngineSlot     `json:"engine_data,omitempty"`
+	WheelSlot      *WheelSlot      `json:"wheel_data,omitempty"`
+	FuelSlot       *FuelSlot       `json:"fuel,omitempty"`
+	GunSlot        *GunSlot        `json:"gun_data,omitempty"`
+	GunmodSlot     *GunmodSlot     `json:"gunmod_data,omitempty"`
+	MagazineSlot   *MagazineSlot   `json:"magazine_data,omitempty"`
+	AmmoSlot       *AmmoSlot       `json:"ammo_data,omitempty"`
+	BionicSlot     *BionicSlot     `json:"bionic_data,omitempty"`
+	SeedSlot       *SeedSlot       `json:"seed_data,omitempty"`
+}
+
+// slotTypes lists the slots each item type reads from the top level of its
+// definition, following the load_* function the game registers for it.
+var slotTypes = map[string][]string{
+	"AMMO":        {"ammo"},
+	"ARMOR":       {"armor"},
+	"BIONIC_ITEM": {"bionic"},
+	"BOOK":        {"book"},
+	"COMESTIBLE":  {"comestible"},
+	"CONTAINER":   {"container"},
+	"ENGINE":      {"engine"},
+	"GENERIC":     {},
+	"GUN":         {"gun"},
+	"GUNMOD":      {"gunmod", "mod"},
+	"MAGAZINE":    {"magazine"},
+	"PET_ARMOR":   {"armor"},
+	"TOOL":        {"tool"},
+	"TOOLMOD":     {"mod"},
+	"TOOL_ARMOR":  {"tool", "armor"},
+	"WHEEL":       {"wheel"},
+}
+
+// optionalSlots are the members any item may use to fill in a slot its type
+// doesn't give it, as load_slot_optional does.
+var optionalSlots = map[string][]string{
+	"container_data": {"container"},
+	"armor_data":     {"armor"},
+	"book_data":      {"book"},
+	"gun_data":       {"gun"},
+	"bionic_data":    {"bionic"},
+	"ammo_data":      {"ammo"},
+	"seed_data":      {"seed"},
+	"brewable":       {"brewable"},
+	"fuel":           {"fuel"},
+	"gunmod_data":    {"gunmod", "mod"},
+}
+
+// ItemMembers lists the members an item of the given type is read from:
+// those every item has, the ones its slots take from the top level, and the
+// *_data members that fill in other slots. ok is false for a type that isn't
+// an item type.
+func ItemMembers(itemType string) (members map[string]bool, ok bool) {
+	slots, ok := slotTypes[itemType]
+	if !ok {
+		return nil, false
+	}
+	t := &ItemType{}
+	for _, s := range slots {
+		if err := t.decodeSlot(s, []byte("{}")); err != nil {
+			return nil, false
+		}
+	}
+
+	members = make(map[string]bool)
+	for k := range optionalSlots {
+		members[k] = true
+	}
+	v := reflect.ValueOf(t).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		f := v.Type().Field(i)
+		if !strings.HasSuffix(f.Name, "Slot") {
+			members[jsonName(f)] = true
+			continue
+		}
+		if v.Field(i).IsNil() {
+			continue
+		}
+		slot := f.Type.Elem()
+		for j := 0; j < slot.NumField(); j++ {
+			members[jsonName(slot.Field(j))] = true
+		}
+	}
+	return members, true
+}
+
+func jsonName(f reflect.StructField) string {
+	return strings.Split(f.Tag.Get("json"), ",")[0]
+}
+
+// DecodeItemType decodes a resolved item definition.
+func DecodeItemType(resolved []byte) (*ItemType, error) {
+	var basic struct {
+		Abstract string `json:"abstract"`
+	}
+	if err := json.Unmarshal(resolved, &basic); err != nil {
+		return nil, err
+	}
+
+	// The slot members are filled in from the top level and the *_data
+	// members below, so keep them out of the first pass.
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(resolved, &members); err != nil {
+		return nil, err
+	}
+	nested := make(map[string]json.RawMessage)
+	for k := range optionalSlots {
+		if b, ok := members[k]; ok {
+			nested[k] = b
+			delete(members, k)
+		}
+	}
+	top, err := json.Marshal(members)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &ItemType{}
+	if err := json.Unmarshal(top, t); err != nil {
+		return nil, err
+	}
+	if t.ID == "" {
+		t.ID = basic.Abstract
+	}
+
+	slots, ok := slotTypes[t.Type]
+	if !ok {
+		return nil, fmt.Errorf("unknown item type %q", t.Type)
+	}
+	for _, s := range slots {
+		if err := t.decodeSlot(s, resolved); err != nil {
+			return nil, fmt.Errorf("%s: %v", s, err)
+		}
+	}
+
+	for k, b := range nested {
+		for _, s := range optionalSlots[k] {
+			if err := t.decodeSlot(s, b); err != nil {
+				return nil, fmt.Errorf("%s: %v", k, err)
+			}
+		}
+	}
+
+	return t, nil
+}
+
+// decodeSlot decodes b into the named slot, creating it if needed. Decoding
+// into an existing slot only replaces the members b gives.
+func (t *ItemType) decodeSlot(name string, b []byte) error {
+	var slot interface{}
+	switch name {
+	case "container":
+		if t.ContainerSlot == nil {
+			t.ContainerSlot = &ContainerSlot{}
+		}
+		slot = t.ContainerSlot
+	case "tool":
+		if t.ToolSlot == nil {
+			t.ToolSlot = &ToolSlot{}
+		}
+		slot = t.ToolSlot
+	case "comestible":
+		if t.ComestibleSlot == nil {
+			t.ComestibleSlot = &ComestibleSlot{}
+		}
+		slot = t.ComestibleSlot
+	case "brewable":
+		if t.BrewableSlot == nil {
+			t.BrewableSlot = &BrewableSlot{}
+		}
+		slot = t.BrewableSlot
+	case "armor":
+		if t.ArmorSlot == nil {
+			t.ArmorSlot = &ArmorSlot{}
+		}
+		slot = t.ArmorSlot
+	case "book":
+		if t.BookSlot == nil {
+			t.BookSlot = &BookSlot{}
+		}
+		slot = t.BookSlot
+	case "mod":
+		if t.ModSlot == nil {
+			t.ModSlot = &ModSlot{}
+		}
+		slot = t.ModSlot
+	case "engine":
+		if t.EngineSlot == nil {
+			t.EngineSlot = &EngineSlot{}
+		}
+		slot = t.EngineSlot
+	case "wheel":
+		if t.WheelSlot == nil {
+			t.WheelSlot = &WheelSlot{}
+		}
+		slot = t.WheelSlot
+	case "fuel":
+		if t.FuelSlot == nil {
+			t.FuelSlot = &FuelSlot{}
+		}
+		slot = t.FuelSlot
+	case "gun":
+		if t.GunSlot == nil {
+			t.GunSlot = &GunSlot{}
+		}
+		slot = t.GunSlot
+	case "gunmod":
+		if t.GunmodSlot == nil {
+			t.GunmodSlot = &GunmodSlot{}
+		}
+		slot = t.GunmodSlot
+	case "magazine":
+		if t.MagazineSlot == nil {
+			t.MagazineSlot = &MagazineSlot{}
+		}
+		slot = t.MagazineSlot
+	case "ammo":
+		if t.AmmoSlot == nil {
+			t.AmmoSlot = &AmmoSlot{}
+		}
+		slot = t.AmmoSlot
+	case "bionic":
+		if t.BionicSlot == nil {
+			t.BionicSlot = &BionicSlot{}
+		}
+		slot = t.BionicSlot
+	case "seed":
+		if t.SeedSlot == nil {
+			t.SeedSlot = &SeedSlot{}
+		}
+		slot = t.SeedSlot
+	default:
+		return fmt.Errorf("unknown slot %q", name)
+	}
+	return json.Unmarshal(b, slot)
+}
@@ -0,0 +1,228 @@
+package cddadb
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/ralreegorganon/cddadb/units"
+)
+
+// The game's JSON reader is forgiving and the data takes advantage of it, a
+// member that is usually a list might be a single string, and a name might
+// be a plain string or an object carrying its plural. The types here decode
+// every shape a member is written in.
+
+// Translation is a name, written either as a string or as an object with
+// singular and plural forms.
+type Translation struct {
+	Str   string `json:"str"`
+	StrPl string `json:"str_pl,omitempty"`
+}
+
+func (t *Translation) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		t.Str = s
+		return nil
+	}
+	type plain Translation
+	return json.Unmarshal(b, (*plain)(t))
+}
+
+// Tags is a list of ids that may be written as a single string.
+type Tags []string
+
+func (t *Tags) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		*t = Tags{s}
+		return nil
+	}
+	var l []string
+	if err := json.Unmarshal(b, &l); err != nil {
+		return err
+	}
+	*t = Tags(l)
+	return nil
+}
+
+// Measure is a value that may be a bare number or a string with a unit, like
+// "250 g".
+type Measure struct {
+	Value float64 `json:"value"`
+	Unit  string  `json:"unit,omitempty"`
+}
+
+func (m *Measure) UnmarshalJSON(b []byte) error {
+	var f float64
+	if err := json.Unmarshal(b, &f); err == nil {
+		*m = Measure{Value: f}
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return fmt.Errorf("measure must be a number or string: %s", b)
+	}
+	var unit string
+	var value float64
+	if _, err := fmt.Sscanf(s, "%g %s", &value, &unit); err != nil {
+		return fmt.Errorf("bad measure %q: %v", s, err)
+	}
+	*m = Measure{Value: value, Unit: unit}
+	return nil
+}
+
+// SpoilTime is how long food keeps, older data gives it in bare hours.
+type SpoilTime units.Duration
+
+func (s *SpoilTime) UnmarshalJSON(b []byte) error {
+	var v interface{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	d, err := units.ParseDuration(v, units.Hours)
+	*s = SpoilTime(d)
+	return err
+}
+
+// GrowTime is how long a plant takes to grow, older data gives it in bare
+// days.
+type GrowTime units.Duration
+
+func (g *GrowTime) UnmarshalJSON(b []byte) error {
+	var v interface{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	d, err := units.ParseDuration(v, units.Days)
+	*g = GrowTime(d)
+	return err
+}
+
+// DamageUnit is one type of damage in a damage instance.
+type DamageUnit struct {
+	DamageType       string  `json:"damage_type"`
+	Amount           float64 `json:"amount"`
+	ArmorPenetration float64 `json:"armor_penetration,omitempty"`
+	ArmorMultiplier  float64 `json:"armor_multiplier,omitempty"`
+	DamageMultiplier float64 `json:"damage_multiplier,omitempty"`
+}
+
+// DamageInstance is written as a bare amount, a single damage unit or a list
+// of them. A bare amount leaves the damage type empty for the owner to fill
+// in, older guns and ammo only ever dealt one kind of damage.
+type DamageInstance []DamageUnit
+
+func (d *DamageInstance) UnmarshalJSON(b []byte) error {
+	var f float64
+	if err := json.Unmarshal(b, &f); err == nil {
+		*d = DamageInstance{{Amount: f}}
+		return nil
+	}
+	var u DamageUnit
+	if err := json.Unmarshal(b, &u); err == nil {
+		*d = DamageInstance{u}
+		return nil
+	}
+	var l []DamageUnit
+	if err := json.Unmarshal(b, &l); err != nil {
+		return err
+	}
+	*d = DamageInstance(l)
+	return nil
+}
+
+// Total sums the amounts of every unit.
+func (d DamageInstance) Total() float64 {
+	t := 0.0
+	for _, u := range d {
+		t += u.Amount
+	}
+	return t
+}
+
+// Levels is a list of [id, level] pairs such as qualities, min_skills and
+// vitamins, decoded into a map.
+type Levels map[string]int
+
+func (l *Levels) UnmarshalJSON(b []byte) error {
+	var pairs [][]interface{}
+	if err := json.Unmarshal(b, &pairs); err != nil {
+		return err
+	}
+	m := make(Levels, len(pairs))
+	for _, p := range pairs {
+		if len(p) != 2 {
+			return fmt.Errorf("expected [id, level] pair: %v", p)
+		}
+		id, ok := p[0].(string)
+		if !ok {
+			return fmt.Errorf("expected [id, level] pair: %v", p)
+		}
+		n, err := number(p[1])
+		if err != nil {
+			return err
+		}
+		m[id] = int(n)
+	}
+	*l = m
+	return nil
+}
+
+// Properties is a list of [key, value] string pairs.
+type Properties map[string]string
+
+func (p *Properties) UnmarshalJSON(b []byte) error {
+	var pairs [][]interface{}
+	if err := json.Unmarshal(b, &pairs); err != nil {
+		return err
+	}
+	m := make(Properties, len(pairs))
+	for _, e := range pairs {
+		if len(e) != 2 {
+			return fmt.Errorf("expected [key, value] pair: %v", e)
+		}
+		m[fmt.Sprint(e[0])] = fmt.Sprint(e[1])
+	}
+	*p = m
+	return nil
+}
+
+// AmmoMagazines maps an ammo type to the magazines that hold it, from
+// [ammo_type, [magazine, ...]] pairs. The first magazine is the default.
+type AmmoMagazines map[string][]string
+
+func (a *AmmoMagazines) UnmarshalJSON(b []byte) error {
+	var pairs [][]json.RawMessage
+	if err := json.Unmarshal(b, &pairs); err != nil {
+		return err
+	}
+	m := make(AmmoMagazines, len(pairs))
+	for _, p := range pairs {
+		if len(p) != 2 {
+			return fmt.Errorf("expected [ammo_type, [magazine, ...]] pair: %s", b)
+		}
+		var ammo string
+		if err := json.Unmarshal(p[0], &ammo); err != nil {
+			return err
+		}
+		var mags Tags
+		if err := json.Unmarshal(p[1], &mags); err != nil {
+			return err
+		}
+		m[ammo] = append(m[ammo], mags...)
+	}
+	*a = m
+	return nil
+}
+
+func number(v interface{}) (float64, error) {
+	switch n := v.(type) {
+	case float64:
+		return n, nil
+	case string:
+		return strconv.ParseFloat(n, 64)
+	}
+	return 0, fmt.Errorf("not a number: %v", v)
+}
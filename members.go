@@ -0,0 +1,192 @@
+package cddadb
+
+import "reflect"
+
+// parsedTypes are the structs each type other than items is parsed into.
+// Recipes are parsed by the loader rather than a struct, so all of their
+// members are listed in unparsedMembers.
+var parsedTypes = map[string]interface{}{
+	"MONSTER":           monsterJSON{},
+	"monstergroup":      monsterGroupJSON{},
+	"mutation":          mutationJSON{},
+	"mutation_category": mutationCategoryJSON{},
+	"bionic":            bionicJSON{},
+	"terrain":           mapObjectJSON{},
+	"furniture":         mapObjectJSON{},
+	"vehicle_part":      vehiclePartJSON{},
+	"vehicle":           vehicleJSON{},
+	"material":          materialJSON{},
+	"item_group":        itemGroupJSON{},
+	"recipe":            struct{}{},
+	"uncraft":           struct{}{},
+	"requirement":       struct{}{},
+}
+
+// recipeMembers are what recipe::load and the requirements it reads take
+// from a recipe or an uncraft.
+var recipeMembers = []string{
+	"result", "id_suffix", "category", "subcategory", "description", "name",
+	"skill_used", "skills_required", "difficulty", "time", "reversible",
+	"autolearn", "book_learn", "never_learn", "decomp_learn", "obsolete",
+	"contained", "container", "charges", "result_mult", "byproducts",
+	"batch_time_factors", "flags", "delete_flags", "activity_level",
+	"proficiencies", "construction_blueprint", "blueprint_name",
+	"blueprint_requires", "blueprint_provides", "blueprint_excludes",
+	"blueprint_resources", "blueprint_autocalc", "check_blueprint_needs",
+	"components", "tools", "qualities", "using",
+}
+
+// unparsedMembers are the members the game reads for each of the parsed
+// types that the API has no use for, so the structs leave them out.
+var unparsedMembers = map[string][]string{
+	"MONSTER": {
+		"name_plural", "categories", "volume", "weight", "phase", "symbol",
+		"color", "looks_like", "luminance", "mountable_weight_ratio",
+		"armor_bullet", "armor_elec", "regenerates", "regenerates_in_dark",
+		"regen_morale", "starting_ammo", "upgrades", "reproduction",
+		"baby_flags", "biosignature", "burn_into", "revert_to_itype",
+		"mech_weapon", "mech_str_bonus", "mech_battery", "zombify_into",
+		"fungalize_into", "harvest", "dissect", "special_when_hit",
+		"anger_triggers", "placate_triggers", "fear_triggers",
+		"path_settings", "absorb_ml_per_hp", "split_move_cost",
+		"absorb_move_cost_per_ml", "absorb_move_cost_min",
+		"absorb_move_cost_max", "bleeds", "scent_tracked", "scent_ignored",
+		"petfood", "tracking_distance", "attack_effs", "grab_strength",
+		"melee_training_cap", "families", "stomach_size", "chat_topics",
+		"shearing", "speed_description", "aggro_character",
+		"status_chance_multiplier",
+	},
+	"monstergroup": {
+		"monsters", "replace_monster_group", "new_monster_group_id",
+		"replacement_time", "auto_total", "override",
+	},
+	"mutation": {
+		"visibility", "ugliness", "cut_dmg_bonus", "pierce_dmg_bonus",
+		"bash_dmg_bonus", "rand_cut_bonus", "rand_bash_bonus",
+		"butchering_quality", "scent_modifier", "scent_intensity",
+		"scent_mask", "scent_type", "bleed_resist", "fat_to_max_hp",
+		"healthy_rate", "weight_capacity_modifier", "hp_modifier",
+		"hp_modifier_secondary", "hp_adjustment", "str_modifier",
+		"dodge_modifier", "speed_modifier", "movecost_modifier",
+		"movecost_flatground_modifier", "movecost_obstacle_modifier",
+		"movecost_swim_modifier", "attackcost_modifier",
+		"max_stamina_modifier", "stealth_modifier", "metabolism_modifier",
+		"thirst_modifier", "fatigue_modifier", "fatigue_regen_modifier",
+		"stamina_regen_modifier", "noise_modifier",
+		"temperature_speed_modifier", "overmap_sight", "overmap_multiplier",
+		"map_memory_capacity_multiplier", "reading_speed_multiplier",
+		"skill_rust_multiplier", "packmule_modifier",
+		"crafting_speed_multiplier", "construction_speed_modifier",
+		"falling_damage_multiplier", "night_vision_range",
+		"mana_modifier", "mana_multiplier", "mana_regen_multiplier",
+		"starting_trait", "debug", "player_display", "vanity",
+		"mixed_effect", "active", "starts_active", "destroys_gear",
+		"allow_soft_gear", "cost", "time", "hunger", "thirst", "fatigue",
+		"kcal", "wet_protection", "encumbrance_always",
+		"encumbrance_covered", "restricts_gear", "armor", "attacks",
+		"social_modifiers", "spawn_item", "ranged_mutation", "transform",
+		"triggers", "vitamin_rates", "vitamins_absorb_multi",
+		"craft_skill_bonus", "lumination", "flags", "allowed_category",
+		"no_cbm_on_bp", "enchantments", "spells_learned",
+		"initial_ma_styles", "bodytemp_modifiers", "bodytemp_sleep",
+	},
+	"mutation_category": {
+		"iv_message", "iv_min", "iv_max", "iv_sound", "iv_sound_message",
+		"iv_sound_id", "iv_sound_variant", "iv_noise", "iv_pain",
+		"iv_sleep", "iv_sleep_message", "iv_sleep_dur", "iv_morale",
+		"iv_morale_max", "iv_additional_hunger", "iv_additional_thirst",
+		"iv_additional_pain", "iv_additional_fatigue",
+		"iv_additional_morale", "junkie_message", "memorial_message",
+		"wildcard", "mutagen_hunger", "mutagen_thirst", "mutagen_pain",
+		"mutagen_fatigue", "mutagen_morale", "vitamin",
+		"base_removal_chance", "base_removal_cost_mul", "threshold_min",
+		"skip_test",
+	},
+	"bionic": {
+		"power_source", "active", "toggled", "faulty", "gun_bionic",
+		"weapon_bionic", "armor_interface", "sleep_friendly",
+		"power_activate", "power_deactivate", "power_over_time",
+		"charge_time", "capacity", "fuel_options", "fuel_capacity",
+		"fuel_efficiency", "passive_fuel_efficiency",
+		"exothermic_power_gen", "power_gen_emission",
+		"coverage_power_gen_penalty", "fake_item", "fake_weapon",
+		"encumbrance", "env_protec", "bash_protec", "cut_protec",
+		"bullet_protec", "stat_bonus", "included", "upgraded_bionic",
+		"available_upgrades", "weight_capacity_bonus",
+		"weight_capacity_modifier", "act_cost", "deact_cost", "react_cost",
+		"time", "learned_spells", "enchantments",
+		"installation_requirement", "spell_on_activation",
+		"social_modifiers", "vitamin_absorb_mod",
+	},
+	"terrain": {
+		"looks_like", "coverage", "comfort", "floor_bedding_warmth",
+		"bonus_fire_warmth_feet", "examine_action", "emissions",
+		"light_emitted", "trap", "transforms_into", "harvest_by_season",
+		"harvestable", "harvest_season", "lockpick_result",
+		"lockpick_message", "boltcut", "hacksaw", "oxytorch", "prying",
+		"shoot", "heat_radiation", "curtain_transform", "liquid_source",
+		"digging_results", "allowed_template_ids",
+	},
+	"furniture": {
+		"looks_like", "coverage", "comfort", "floor_bedding_warmth",
+		"bonus_fire_warmth_feet", "examine_action", "emissions",
+		"light_emitted", "transforms_into", "harvest_by_season",
+		"lockpick_result", "lockpick_message", "boltcut", "hacksaw",
+		"oxytorch", "prying", "shoot", "keg_capacity", "workbench",
+		"plant_data", "surgery_skill_multiplier",
+	},
+	"vehicle_part": {
+		"symbol", "symbols", "standard_symbols", "broken_symbol", "color",
+		"broken_color", "looks_like", "damage_modifier", "folded_volume",
+		"difficulty", "qualities", "requirements", "cargo_weight_modifier",
+		"m2c", "noise_factor", "wheel_type", "contact_area",
+		"wheel_terrain_mod", "wheel_or_rating", "bonus", "range", "comfort",
+		"floor_bedding_warmth", "bonus_fire_warmth_feet",
+		"transform_terrain", "pseudo_tools", "categories",
+		"control_requirements", "damage_reduction", "variants",
+		"variants_bases", "fuel_options", "workbench", "rotor_diameter",
+		"muscle_power_factor", "exclusions", "emissions", "exhaust",
+	},
+	"vehicle": {
+		"blueprint_origin", "items", "zones", "flags",
+	},
+	"material": {
+		"bullet_resist", "specific_heat_liquid", "specific_heat_solid",
+		"latent_heat", "freezing_point", "edible", "rotting", "soft",
+		"reinforces", "salvaged_into", "repaired_with", "dmg_adj",
+		"bash_dmg_verb", "cut_dmg_verb", "burn_data", "burn_products",
+		"fuel_data", "compact_accepts", "compacts_into", "vitamins",
+		"wind_resist", "warmth_when_wet", "breathability",
+		"sheet_thickness", "conductive",
+	},
+	"item_group": {
+		"on_overflow", "ammo", "magazine",
+	},
+	"recipe":      recipeMembers,
+	"uncraft":     recipeMembers,
+	"requirement": {"name", "components", "tools", "qualities", "using"},
+}
+
+// Members lists the members a definition of the given type is read from, for
+// items and the other types the API parses. ok is false for any other type.
+func Members(objectType string) (members map[string]bool, ok bool) {
+	if members, ok := ItemMembers(objectType); ok {
+		return members, true
+	}
+	parsed, ok := parsedTypes[objectType]
+	if !ok {
+		return nil, false
+	}
+
+	members = map[string]bool{"id": true, "type": true}
+	t := reflect.TypeOf(parsed)
+	for i := 0; i < t.NumField(); i++ {
+		if name := jsonName(t.Field(i)); name != "" && name != "-" {
+			members[name] = true
+		}
+	}
+	for _, m := range unparsedMembers[objectType] {
+		members[m] = true
+	}
+	return members, true
+}
@@ -0,0 +1,33 @@
+drop view item_group;
+drop view skill;
+drop view bionic;
+drop view mutation;
+drop view material;
+drop view overmap_terrain;
+drop view furniture;
+drop view terrain;
+drop view vehicle_part;
+drop view recipe;
+drop view monster;
+drop view item;
+
+create table item
+(
+    item_id serial not null,
+    abstract character varying,
+    id character varying,
+    type character varying not null,
+    source character varying not null,
+    raw jsonb not null,
+    constraint item_pkey primary key (item_id)
+);
+
+insert into item (abstract, id, type, source, raw)
+select abstract, id, type, source, raw
+from game_object
+where type in (
+    'AMMO', 'ARMOR', 'BIONIC_ITEM', 'BOOK', 'COMESTIBLE', 'CONTAINER', 'ENGINE', 'GENERIC',
+    'GUN', 'GUNMOD', 'MAGAZINE', 'PET_ARMOR', 'TOOL', 'TOOLMOD', 'TOOL_ARMOR', 'WHEEL'
+);
+
+drop table game_object;
@@ -0,0 +1,81 @@
+create table game_object
+(
+    game_object_id serial not null,
+    id character varying,
+    abstract character varying,
+    type character varying not null,
+    source character varying not null,
+    raw jsonb not null,
+    constraint game_object_pkey primary key (game_object_id)
+);
+
+create index game_object_type_idx on game_object (type);
+create index game_object_id_idx on game_object (id);
+
+insert into game_object (id, abstract, type, source, raw)
+select id, abstract, type, source, raw from item;
+
+drop table item;
+
+create view item as
+select game_object_id, id, abstract, type, source, raw
+from game_object
+where type in (
+    'AMMO', 'ARMOR', 'BIONIC_ITEM', 'BOOK', 'COMESTIBLE', 'CONTAINER', 'ENGINE', 'GENERIC',
+    'GUN', 'GUNMOD', 'MAGAZINE', 'PET_ARMOR', 'TOOL', 'TOOLMOD', 'TOOL_ARMOR', 'WHEEL'
+);
+
+create view monster as
+select game_object_id, id, abstract, source, raw->>'name' as name, raw
+from game_object
+where type = 'MONSTER';
+
+create view recipe as
+select game_object_id, id, source, raw->>'result' as result, raw->>'category' as category, raw
+from game_object
+where type = 'recipe';
+
+create view vehicle_part as
+select game_object_id, id, abstract, source, raw->>'name' as name, raw->>'item' as item, raw
+from game_object
+where type = 'vehicle_part';
+
+create view terrain as
+select game_object_id, id, abstract, source, raw->>'name' as name, raw
+from game_object
+where type = 'terrain';
+
+create view furniture as
+select game_object_id, id, abstract, source, raw->>'name' as name, raw
+from game_object
+where type = 'furniture';
+
+create view overmap_terrain as
+select game_object_id, id, abstract, source, raw->>'name' as name, raw
+from game_object
+where type = 'overmap_terrain';
+
+create view material as
+select game_object_id, id, source, raw->>'name' as name, raw
+from game_object
+where type = 'material';
+
+create view mutation as
+select game_object_id, id, source, raw->>'name' as name, raw
+from game_object
+where type = 'mutation';
+
+create view bionic as
+select game_object_id, id, source, raw->>'name' as name, raw
+from game_object
+where type = 'bionic';
+
+create view skill as
+select game_object_id, id, source, raw->>'name' as name, raw
+from game_object
+where type = 'skill';
+
+create view item_group as
+select game_object_id, id, source, raw->>'subtype' as subtype, raw
+from game_object
+where type = 'item_group';
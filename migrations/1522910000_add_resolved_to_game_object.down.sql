@@ -0,0 +1,77 @@
+drop view item_group;
+drop view skill;
+drop view bionic;
+drop view mutation;
+drop view material;
+drop view overmap_terrain;
+drop view furniture;
+drop view terrain;
+drop view vehicle_part;
+drop view recipe;
+drop view monster;
+drop view item;
+
+alter table game_object drop column resolved;
+
+create view item as
+select game_object_id, id, abstract, type, source, raw
+from game_object
+where type in (
+    'AMMO', 'ARMOR', 'BIONIC_ITEM', 'BOOK', 'COMESTIBLE', 'CONTAINER', 'ENGINE', 'GENERIC',
+    'GUN', 'GUNMOD', 'MAGAZINE', 'PET_ARMOR', 'TOOL', 'TOOLMOD', 'TOOL_ARMOR', 'WHEEL'
+);
+
+create view monster as
+select game_object_id, id, abstract, source, raw->>'name' as name, raw
+from game_object
+where type = 'MONSTER';
+
+create view recipe as
+select game_object_id, id, source, raw->>'result' as result, raw->>'category' as category, raw
+from game_object
+where type = 'recipe';
+
+create view vehicle_part as
+select game_object_id, id, abstract, source, raw->>'name' as name, raw->>'item' as item, raw
+from game_object
+where type = 'vehicle_part';
+
+create view terrain as
+select game_object_id, id, abstract, source, raw->>'name' as name, raw
+from game_object
+where type = 'terrain';
+
+create view furniture as
+select game_object_id, id, abstract, source, raw->>'name' as name, raw
+from game_object
+where type = 'furniture';
+
+create view overmap_terrain as
+select game_object_id, id, abstract, source, raw->>'name' as name, raw
+from game_object
+where type = 'overmap_terrain';
+
+create view material as
+select game_object_id, id, source, raw->>'name' as name, raw
+from game_object
+where type = 'material';
+
+create view mutation as
+select game_object_id, id, source, raw->>'name' as name, raw
+from game_object
+where type = 'mutation';
+
+create view bionic as
+select game_object_id, id, source, raw->>'name' as name, raw
+from game_object
+where type = 'bionic';
+
+create view skill as
+select game_object_id, id, source, raw->>'name' as name, raw
+from game_object
+where type = 'skill';
+
+create view item_group as
+select game_object_id, id, source, raw->>'subtype' as subtype, raw
+from game_object
+where type = 'item_group';
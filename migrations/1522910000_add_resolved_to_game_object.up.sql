@@ -0,0 +1,64 @@
+alter table game_object add column resolved jsonb;
+
+create or replace view item as
+select game_object_id, id, abstract, type, source, raw, resolved
+from game_object
+where type in (
+    'AMMO', 'ARMOR', 'BIONIC_ITEM', 'BOOK', 'COMESTIBLE', 'CONTAINER', 'ENGINE', 'GENERIC',
+    'GUN', 'GUNMOD', 'MAGAZINE', 'PET_ARMOR', 'TOOL', 'TOOLMOD', 'TOOL_ARMOR', 'WHEEL'
+);
+
+create or replace view monster as
+select game_object_id, id, abstract, source, resolved->>'name' as name, raw, resolved
+from game_object
+where type = 'MONSTER';
+
+create or replace view recipe as
+select game_object_id, id, source, resolved->>'result' as result, resolved->>'category' as category, raw, resolved
+from game_object
+where type = 'recipe';
+
+create or replace view vehicle_part as
+select game_object_id, id, abstract, source, resolved->>'name' as name, resolved->>'item' as item, raw, resolved
+from game_object
+where type = 'vehicle_part';
+
+create or replace view terrain as
+select game_object_id, id, abstract, source, resolved->>'name' as name, raw, resolved
+from game_object
+where type = 'terrain';
+
+create or replace view furniture as
+select game_object_id, id, abstract, source, resolved->>'name' as name, raw, resolved
+from game_object
+where type = 'furniture';
+
+create or replace view overmap_terrain as
+select game_object_id, id, abstract, source, resolved->>'name' as name, raw, resolved
+from game_object
+where type = 'overmap_terrain';
+
+create or replace view material as
+select game_object_id, id, source, resolved->>'name' as name, raw, resolved
+from game_object
+where type = 'material';
+
+create or replace view mutation as
+select game_object_id, id, source, resolved->>'name' as name, raw, resolved
+from game_object
+where type = 'mutation';
+
+create or replace view bionic as
+select game_object_id, id, source, resolved->>'name' as name, raw, resolved
+from game_object
+where type = 'bionic';
+
+create or replace view skill as
+select game_object_id, id, source, resolved->>'name' as name, raw, resolved
+from game_object
+where type = 'skill';
+
+create or replace view item_group as
+select game_object_id, id, source, resolved->>'subtype' as subtype, raw, resolved
+from game_object
+where type = 'item_group';
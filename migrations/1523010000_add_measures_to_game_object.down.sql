@@ -0,0 +1,20 @@
+drop view bionic;
+drop view item;
+
+alter table game_object drop column spoils_in;
+alter table game_object drop column energy;
+alter table game_object drop column volume;
+alter table game_object drop column weight;
+
+create view item as
+select game_object_id, id, abstract, type, source, raw, resolved
+from game_object
+where type in (
+    'AMMO', 'ARMOR', 'BIONIC_ITEM', 'BOOK', 'COMESTIBLE', 'CONTAINER', 'ENGINE', 'GENERIC',
+    'GUN', 'GUNMOD', 'MAGAZINE', 'PET_ARMOR', 'TOOL', 'TOOLMOD', 'TOOL_ARMOR', 'WHEEL'
+);
+
+create view bionic as
+select game_object_id, id, source, resolved->>'name' as name, raw, resolved
+from game_object
+where type = 'bionic';
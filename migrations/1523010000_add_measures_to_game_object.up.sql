@@ -0,0 +1,25 @@
+alter table game_object add column weight bigint;
+alter table game_object add column volume bigint;
+alter table game_object add column energy bigint;
+alter table game_object add column spoils_in bigint;
+
+comment on column game_object.weight is 'grams';
+comment on column game_object.volume is 'millilitres';
+comment on column game_object.energy is 'millijoules';
+comment on column game_object.spoils_in is 'turns';
+
+create index game_object_weight_idx on game_object (weight);
+create index game_object_volume_idx on game_object (volume);
+
+create or replace view item as
+select game_object_id, id, abstract, type, source, raw, resolved, weight, volume, spoils_in
+from game_object
+where type in (
+    'AMMO', 'ARMOR', 'BIONIC_ITEM', 'BOOK', 'COMESTIBLE', 'CONTAINER', 'ENGINE', 'GENERIC',
+    'GUN', 'GUNMOD', 'MAGAZINE', 'PET_ARMOR', 'TOOL', 'TOOLMOD', 'TOOL_ARMOR', 'WHEEL'
+);
+
+create or replace view bionic as
+select game_object_id, id, source, resolved->>'name' as name, raw, resolved, energy as capacity
+from game_object
+where type = 'bionic';
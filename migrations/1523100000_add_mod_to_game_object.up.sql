@@ -0,0 +1,11 @@
+alter table game_object add column mod character varying not null default 'dda';
+
+create index game_object_mod_idx on game_object (mod);
+
+create or replace view item as
+select game_object_id, id, abstract, type, source, raw, resolved, weight, volume, spoils_in, mod
+from game_object
+where type in (
+    'AMMO', 'ARMOR', 'BIONIC_ITEM', 'BOOK', 'COMESTIBLE', 'CONTAINER', 'ENGINE', 'GENERIC',
+    'GUN', 'GUNMOD', 'MAGAZINE', 'PET_ARMOR', 'TOOL', 'TOOLMOD', 'TOOL_ARMOR', 'WHEEL'
+);
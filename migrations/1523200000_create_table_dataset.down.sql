@@ -0,0 +1,79 @@
+drop view item;
+drop view monster;
+drop view recipe;
+drop view vehicle_part;
+drop view terrain;
+drop view furniture;
+drop view overmap_terrain;
+drop view material;
+drop view mutation;
+drop view bionic;
+drop view skill;
+drop view item_group;
+
+alter table game_object drop column dataset_id;
+
+drop table dataset;
+
+create view item as
+select game_object_id, id, abstract, type, source, raw, resolved, weight, volume, spoils_in, mod
+from game_object
+where type in (
+    'AMMO', 'ARMOR', 'BIONIC_ITEM', 'BOOK', 'COMESTIBLE', 'CONTAINER', 'ENGINE', 'GENERIC',
+    'GUN', 'GUNMOD', 'MAGAZINE', 'PET_ARMOR', 'TOOL', 'TOOLMOD', 'TOOL_ARMOR', 'WHEEL'
+);
+
+create view monster as
+select game_object_id, id, abstract, source, resolved->>'name' as name, raw, resolved
+from game_object
+where type = 'MONSTER';
+
+create view recipe as
+select game_object_id, id, source, resolved->>'result' as result, resolved->>'category' as category, raw, resolved
+from game_object
+where type = 'recipe';
+
+create view vehicle_part as
+select game_object_id, id, abstract, source, resolved->>'name' as name, resolved->>'item' as item, raw, resolved
+from game_object
+where type = 'vehicle_part';
+
+create view terrain as
+select game_object_id, id, abstract, source, resolved->>'name' as name, raw, resolved
+from game_object
+where type = 'terrain';
+
+create view furniture as
+select game_object_id, id, abstract, source, resolved->>'name' as name, raw, resolved
+from game_object
+where type = 'furniture';
+
+create view overmap_terrain as
+select game_object_id, id, abstract, source, resolved->>'name' as name, raw, resolved
+from game_object
+where type = 'overmap_terrain';
+
+create view material as
+select game_object_id, id, source, resolved->>'name' as name, raw, resolved
+from game_object
+where type = 'material';
+
+create view mutation as
+select game_object_id, id, source, resolved->>'name' as name, raw, resolved
+from game_object
+where type = 'mutation';
+
+create view bionic as
+select game_object_id, id, source, resolved->>'name' as name, raw, resolved, energy as capacity
+from game_object
+where type = 'bionic';
+
+create view skill as
+select game_object_id, id, source, resolved->>'name' as name, raw, resolved
+from game_object
+where type = 'skill';
+
+create view item_group as
+select game_object_id, id, source, resolved->>'subtype' as subtype, raw, resolved
+from game_object
+where type = 'item_group';
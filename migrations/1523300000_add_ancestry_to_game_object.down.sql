@@ -0,0 +1,11 @@
+drop view item;
+
+alter table game_object drop column ancestry;
+
+create view item as
+select game_object_id, id, abstract, type, source, raw, resolved, weight, volume, spoils_in, mod, dataset_id
+from game_object
+where type in (
+    'AMMO', 'ARMOR', 'BIONIC_ITEM', 'BOOK', 'COMESTIBLE', 'CONTAINER', 'ENGINE', 'GENERIC',
+    'GUN', 'GUNMOD', 'MAGAZINE', 'PET_ARMOR', 'TOOL', 'TOOLMOD', 'TOOL_ARMOR', 'WHEEL'
+);
@@ -0,0 +1,11 @@
+alter table game_object add column ancestry text[];
+
+comment on column game_object.ancestry is 'copy-from chain, nearest parent first';
+
+create or replace view item as
+select game_object_id, id, abstract, type, source, raw, resolved, weight, volume, spoils_in, mod, dataset_id, ancestry
+from game_object
+where type in (
+    'AMMO', 'ARMOR', 'BIONIC_ITEM', 'BOOK', 'COMESTIBLE', 'CONTAINER', 'ENGINE', 'GENERIC',
+    'GUN', 'GUNMOD', 'MAGAZINE', 'PET_ARMOR', 'TOOL', 'TOOLMOD', 'TOOL_ARMOR', 'WHEEL'
+);
@@ -0,0 +1,15 @@
+drop view item;
+
+drop trigger game_object_search on game_object;
+
+drop function game_object_search();
+
+alter table game_object drop column search;
+
+create view item as
+select game_object_id, id, abstract, type, source, raw, resolved, weight, volume, spoils_in, mod, dataset_id, ancestry
+from game_object
+where type in (
+    'AMMO', 'ARMOR', 'BIONIC_ITEM', 'BOOK', 'COMESTIBLE', 'CONTAINER', 'ENGINE', 'GENERIC',
+    'GUN', 'GUNMOD', 'MAGAZINE', 'PET_ARMOR', 'TOOL', 'TOOLMOD', 'TOOL_ARMOR', 'WHEEL'
+);
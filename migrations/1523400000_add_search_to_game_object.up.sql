@@ -0,0 +1,37 @@
+-- The search column is kept up to date by a trigger rather than being a
+-- generated column because generated columns need Postgres 12, and the
+-- database this runs against is Postgres 10 (build/docker-compose.yml). The
+-- update after it fills in the rows loaded before the trigger existed.
+--
+-- Everything is indexed with the english configuration, as the API queries
+-- with to_tsquery('english', ...); ids indexed with another configuration
+-- wouldn't match words the query stems.
+alter table game_object add column search tsvector;
+
+comment on column game_object.search is 'id, name, plural name and description for full-text search';
+
+create function game_object_search() returns trigger as $$
+begin
+    new.search :=
+        setweight(to_tsvector('english', coalesce(new.id, new.abstract, '') || ' ' || replace(coalesce(new.id, new.abstract, ''), '_', ' ')), 'A') ||
+        setweight(to_tsvector('english', coalesce(new.resolved#>>'{name,str}', new.resolved->>'name', '')), 'A') ||
+        setweight(to_tsvector('english', coalesce(new.resolved#>>'{name,str_pl}', new.resolved->>'name_plural', '')), 'B') ||
+        setweight(to_tsvector('english', coalesce(new.resolved->>'description', '')), 'C');
+    return new;
+end
+$$ language plpgsql;
+
+create trigger game_object_search before insert or update of id, abstract, resolved on game_object
+for each row execute procedure game_object_search();
+
+update game_object set resolved = resolved;
+
+create index game_object_search_idx on game_object using gin (search);
+
+create or replace view item as
+select game_object_id, id, abstract, type, source, raw, resolved, weight, volume, spoils_in, mod, dataset_id, ancestry, search
+from game_object
+where type in (
+    'AMMO', 'ARMOR', 'BIONIC_ITEM', 'BOOK', 'COMESTIBLE', 'CONTAINER', 'ENGINE', 'GENERIC',
+    'GUN', 'GUNMOD', 'MAGAZINE', 'PET_ARMOR', 'TOOL', 'TOOLMOD', 'TOOL_ARMOR', 'WHEEL'
+);
@@ -0,0 +1,5 @@
+drop table recipe_skill;
+drop table recipe_quality;
+drop table recipe_tool;
+drop table recipe_component;
+drop table recipe_definition;
@@ -0,0 +1,79 @@
+create table recipe_definition (
+    dataset_id integer not null references dataset (dataset_id) on delete cascade,
+    recipe_type character varying not null,
+    recipe_id character varying not null,
+    result character varying not null,
+    category character varying,
+    subcategory character varying,
+    skill_used character varying,
+    difficulty integer not null default 0,
+    time bigint,
+    reversible boolean not null default false,
+    primary key (dataset_id, recipe_type, recipe_id)
+);
+
+comment on column recipe_definition.recipe_type is 'recipe or uncraft';
+comment on column recipe_definition.time is 'moves, 100 to a turn';
+
+create index recipe_definition_result_idx on recipe_definition (dataset_id, result);
+
+create table recipe_component (
+    dataset_id integer not null,
+    recipe_type character varying not null,
+    recipe_id character varying not null,
+    group_index integer not null,
+    alternative_index integer not null,
+    item_id character varying not null,
+    count integer not null,
+    via character varying,
+    foreign key (dataset_id, recipe_type, recipe_id) references recipe_definition on delete cascade
+);
+
+comment on column recipe_component.via is 'requirement the component was expanded from';
+
+create index recipe_component_recipe_idx on recipe_component (dataset_id, recipe_type, recipe_id);
+create index recipe_component_item_idx on recipe_component (dataset_id, item_id);
+
+create table recipe_tool (
+    dataset_id integer not null,
+    recipe_type character varying not null,
+    recipe_id character varying not null,
+    group_index integer not null,
+    alternative_index integer not null,
+    item_id character varying not null,
+    charges integer not null,
+    via character varying,
+    foreign key (dataset_id, recipe_type, recipe_id) references recipe_definition on delete cascade
+);
+
+comment on column recipe_tool.charges is '-1 when the tool uses no charges';
+comment on column recipe_tool.via is 'requirement the tool was expanded from';
+
+create index recipe_tool_recipe_idx on recipe_tool (dataset_id, recipe_type, recipe_id);
+create index recipe_tool_item_idx on recipe_tool (dataset_id, item_id);
+
+create table recipe_quality (
+    dataset_id integer not null,
+    recipe_type character varying not null,
+    recipe_id character varying not null,
+    group_index integer not null,
+    alternative_index integer not null,
+    quality_id character varying not null,
+    level integer not null,
+    amount integer not null,
+    via character varying,
+    foreign key (dataset_id, recipe_type, recipe_id) references recipe_definition on delete cascade
+);
+
+create index recipe_quality_recipe_idx on recipe_quality (dataset_id, recipe_type, recipe_id);
+
+create table recipe_skill (
+    dataset_id integer not null,
+    recipe_type character varying not null,
+    recipe_id character varying not null,
+    skill_id character varying not null,
+    level integer not null,
+    foreign key (dataset_id, recipe_type, recipe_id) references recipe_definition on delete cascade
+);
+
+create index recipe_skill_recipe_idx on recipe_skill (dataset_id, recipe_type, recipe_id);
@@ -0,0 +1,12 @@
+create table item_group_member (
+    dataset_id integer not null references dataset (dataset_id) on delete cascade,
+    group_id character varying not null,
+    member_type character varying not null,
+    member_id character varying not null,
+    primary key (dataset_id, group_id, member_type, member_id)
+);
+
+comment on table item_group_member is 'items and groups an item group can spawn directly, inline groups flattened into their parent';
+comment on column item_group_member.member_type is 'item or group';
+
+create index item_group_member_member_idx on item_group_member (dataset_id, member_type, member_id);
@@ -0,0 +1,3 @@
+drop view monstergroup;
+drop view monster_faction;
+drop view species;
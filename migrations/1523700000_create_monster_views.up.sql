@@ -0,0 +1,14 @@
+create view species as
+select game_object_id, id, source, raw, resolved, dataset_id
+from game_object
+where type = 'SPECIES';
+
+create view monster_faction as
+select game_object_id, id, source, resolved->>'base_faction' as base_faction, raw, resolved, dataset_id
+from game_object
+where type = 'MONSTER_FACTION';
+
+create view monstergroup as
+select game_object_id, id, source, resolved->>'default' as default_monster, raw, resolved, dataset_id
+from game_object
+where type = 'monstergroup';
@@ -0,0 +1,6 @@
+delete from recipe_definition where recipe_type = 'vehicle_part_install';
+
+comment on column recipe_definition.result is null;
+comment on column recipe_definition.recipe_type is 'recipe or uncraft';
+
+drop view vehicle;
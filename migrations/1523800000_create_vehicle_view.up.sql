@@ -0,0 +1,7 @@
+create view vehicle as
+select game_object_id, id, source, resolved->>'name' as name, raw, resolved, dataset_id
+from game_object
+where type = 'vehicle';
+
+comment on column recipe_definition.recipe_type is 'recipe, uncraft or vehicle_part_install';
+comment on column recipe_definition.result is 'item made, or for vehicle_part_install the item the part is installed from';
@@ -0,0 +1 @@
+drop view mutation_category;
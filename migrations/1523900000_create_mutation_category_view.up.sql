@@ -0,0 +1,4 @@
+create view mutation_category as
+select game_object_id, id, source, resolved->>'name' as name, resolved->>'threshold_mut' as threshold_mut, raw, resolved, dataset_id
+from game_object
+where type = 'mutation_category';
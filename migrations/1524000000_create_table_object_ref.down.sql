@@ -0,0 +1 @@
+drop table object_ref;
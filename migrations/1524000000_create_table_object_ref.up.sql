@@ -0,0 +1,14 @@
+create table object_ref (
+    dataset_id integer not null references dataset (dataset_id) on delete cascade,
+    ref_id character varying not null,
+    object_type character varying not null,
+    object_id character varying,
+    source character varying not null,
+    mod character varying not null,
+    path character varying not null
+);
+
+comment on table object_ref is 'every place a definition, as written, names the id of another object';
+comment on column object_ref.path is 'where in the referring definition the id is written, like components[0][1][0]';
+
+create index object_ref_ref_idx on object_ref (dataset_id, ref_id);
@@ -0,0 +1,30 @@
+create table comestible (
+    dataset_id integer not null references dataset (dataset_id) on delete cascade,
+    id character varying not null,
+    mod character varying not null,
+    name character varying,
+    comestible_type character varying,
+    charges integer not null,
+    calories integer not null,
+    quench integer not null,
+    fun integer not null,
+    stim integer not null,
+    healthy integer not null,
+    parasites integer not null,
+    radiation integer not null,
+    addiction_type character varying,
+    addiction_potential integer not null,
+    spoils_in bigint,
+    weight bigint,
+    volume bigint,
+    materials text[] not null,
+    vitamins jsonb not null,
+    primary key (dataset_id, id)
+);
+
+comment on table comestible is 'the comestible slot of every food, drink and medicine in effect once all mods have loaded';
+comment on column comestible.calories is 'kcal per charge, converted from nutrition for older data';
+comment on column comestible.spoils_in is 'turns, null for comestibles that never spoil';
+comment on column comestible.weight is 'grams per charge';
+comment on column comestible.volume is 'millilitres for all of the default charges';
+comment on column comestible.vitamins is 'percent of the daily requirement per charge, by vitamin';
@@ -0,0 +1,2 @@
+drop table translation;
+drop table language;
@@ -0,0 +1,27 @@
+create table language (
+    dataset_id integer not null references dataset (dataset_id) on delete cascade,
+    lang character varying not null,
+    nplurals integer not null,
+    plural_forms character varying not null,
+    primary key (dataset_id, lang)
+);
+
+comment on table language is 'the languages translations were loaded for';
+comment on column language.plural_forms is 'the Plural-Forms header of the catalog, which says which form a count takes';
+
+create table translation (
+    dataset_id integer not null,
+    lang character varying not null,
+    namespace character varying not null,
+    id character varying not null,
+    name character varying,
+    name_forms text[] not null,
+    description character varying,
+    primary key (dataset_id, lang, namespace, id),
+    foreign key (dataset_id, lang) references language (dataset_id, lang) on delete cascade
+);
+
+comment on table translation is 'names and descriptions of objects in each loaded language';
+comment on column translation.namespace is 'item for every item type, otherwise the object type';
+comment on column translation.name is 'the name as a single object';
+comment on column translation.name_forms is 'every plural form of the name, numbered as in language.plural_forms';
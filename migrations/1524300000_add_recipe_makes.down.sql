@@ -0,0 +1 @@
+alter table recipe_definition drop column makes;
@@ -0,0 +1,3 @@
+alter table recipe_definition add column makes integer not null default 1;
+
+comment on column recipe_definition.makes is 'how many of the result one batch makes, counting charges';
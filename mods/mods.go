@@ -0,0 +1,266 @@
+// Package mods finds game mods, works out the order the game loads them in
+// and lists the JSON files they contribute.
+package mods
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// CoreID is the id of the mod that carries the base game data.
+const CoreID = "dda"
+
+// Mod is one mod as described by its modinfo.json.
+type Mod struct {
+	ID           string   `json:"id"`
+	Name         string   `json:"name"`
+	Description  string   `json:"description,omitempty"`
+	Category     string   `json:"category,omitempty"`
+	Authors      []string `json:"authors,omitempty"`
+	Maintainers  []string `json:"maintainers,omitempty"`
+	Dependencies []string `json:"dependencies,omitempty"`
+	Core         bool     `json:"core,omitempty"`
+	Obsolete     bool     `json:"obsolete,omitempty"`
+	// Path is the directory the mod's JSON is read from.
+	Path string `json:"-"`
+}
+
+type modInfo struct {
+	Type         string   `json:"type"`
+	ID           string   `json:"id"`
+	Ident        string   `json:"ident"`
+	Name         string   `json:"name"`
+	Description  string   `json:"description"`
+	Category     string   `json:"category"`
+	Authors      []string `json:"authors"`
+	Maintainers  []string `json:"maintainers"`
+	Dependencies []string `json:"dependencies"`
+	Core         bool     `json:"core"`
+	Obsolete     bool     `json:"obsolete"`
+	Path         string   `json:"path"`
+}
+
+// Discover reads the modinfo.json of every mod below the given roots. The
+// core data in jsonRoot is always available as the dda mod, even in trees
+// too old to describe it with a modinfo.json of its own.
+func Discover(jsonRoot string, roots ...string) (map[string]*Mod, error) {
+	available := make(map[string]*Mod)
+
+	for _, root := range roots {
+		err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if info.IsDir() || info.Name() != "modinfo.json" {
+				return nil
+			}
+			found, err := readModInfo(path)
+			if err != nil {
+				return err
+			}
+			for _, m := range found {
+				if _, ok := available[m.ID]; ok {
+					return fmt.Errorf("%s: mod %s is defined more than once", path, m.ID)
+				}
+				available[m.ID] = m
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if _, ok := available[CoreID]; !ok {
+		available[CoreID] = &Mod{
+			ID:   CoreID,
+			Name: "Dark Days Ahead",
+			Core: true,
+			Path: jsonRoot,
+		}
+	}
+
+	return available, nil
+}
+
+func readModInfo(path string) ([]*Mod, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var infos []modInfo
+	b = bytes.TrimSpace(b)
+	if bytes.HasPrefix(b, []byte("{")) {
+		var i modInfo
+		err = json.Unmarshal(b, &i)
+		infos = append(infos, i)
+	} else {
+		err = json.Unmarshal(b, &infos)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+
+	dir := filepath.Dir(path)
+	found := []*Mod{}
+	for _, i := range infos {
+		if i.Type != "MOD_INFO" {
+			continue
+		}
+		id := i.ID
+		if id == "" {
+			id = i.Ident
+		}
+		if id == "" {
+			return nil, fmt.Errorf("%s: mod without an id", path)
+		}
+		found = append(found, &Mod{
+			ID:           id,
+			Name:         i.Name,
+			Description:  i.Description,
+			Category:     i.Category,
+			Authors:      i.Authors,
+			Maintainers:  i.Maintainers,
+			Dependencies: i.Dependencies,
+			Core:         i.Core,
+			Obsolete:     i.Obsolete,
+			Path:         filepath.Join(dir, i.Path),
+		})
+	}
+	return found, nil
+}
+
+// LoadOrder returns the requested mods along with everything they depend on,
+// in the order the game loads them. Core mods come first, and every other
+// mod comes after its dependencies but otherwise keeps its requested place.
+func LoadOrder(available map[string]*Mod, requested []string) ([]*Mod, error) {
+	order := []*Mod{}
+	done := make(map[string]bool)
+	visiting := make(map[string]bool)
+
+	var visit func(id string, chain []string) error
+	visit = func(id string, chain []string) error {
+		if done[id] {
+			return nil
+		}
+		if visiting[id] {
+			return fmt.Errorf("mod dependency cycle: %s", strings.Join(append(chain, id), " -> "))
+		}
+		m, ok := available[id]
+		if !ok {
+			if len(chain) > 0 {
+				return fmt.Errorf("mod %s needs %s, which isn't available", chain[len(chain)-1], id)
+			}
+			return fmt.Errorf("mod %s isn't available", id)
+		}
+
+		visiting[id] = true
+		for _, d := range m.Dependencies {
+			if err := visit(d, append(chain, id)); err != nil {
+				return err
+			}
+		}
+		visiting[id] = false
+
+		done[id] = true
+		order = append(order, m)
+		return nil
+	}
+
+	cores := []string{}
+	rest := []string{}
+	for _, id := range requested {
+		if m, ok := available[id]; ok && m.Core {
+			cores = append(cores, id)
+		} else {
+			rest = append(rest, id)
+		}
+	}
+	if len(cores) == 0 {
+		cores = append(cores, CoreID)
+	}
+
+	for _, id := range append(cores, rest...) {
+		if err := visit(id, nil); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
+
+// FromSave reads the ids of the mods a save was played with from its
+// mods.json.
+func FromSave(save string) ([]string, error) {
+	b, err := ioutil.ReadFile(filepath.Join(save, "mods.json"))
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	if err := json.Unmarshal(b, &ids); err != nil {
+		return nil, fmt.Errorf("%s: %v", filepath.Join(save, "mods.json"), err)
+	}
+	return ids, nil
+}
+
+// File is a JSON file along with the mod it belongs to.
+type File struct {
+	Path string
+	Mod  *Mod
+}
+
+// Files lists the JSON files of the given mods in load order. Within a mod,
+// files nearer the top of the tree come first and ties go alphabetically.
+func Files(order []*Mod) ([]File, error) {
+	files := []File{}
+	for _, m := range order {
+		paths := []string{}
+		err := filepath.Walk(m.Path, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if info.IsDir() || info.Name() == "modinfo.json" {
+				return nil
+			}
+			if strings.HasSuffix(path, ".json") {
+				paths = append(paths, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		sort.Sort(inLoadOrder(paths))
+		for _, p := range paths {
+			files = append(files, File{Path: p, Mod: m})
+		}
+	}
+	return files, nil
+}
+
+type inLoadOrder []string
+
+func (s inLoadOrder) Len() int {
+	return len(s)
+}
+
+func (s inLoadOrder) Swap(i, j int) {
+	s[i], s[j] = s[j], s[i]
+}
+
+func (s inLoadOrder) Less(i, j int) bool {
+	c1 := strings.Count(s[i], "/")
+	c2 := strings.Count(s[j], "/")
+
+	if c1 == c2 {
+		return s[i] < s[j]
+	}
+	return c1 < c2
+}
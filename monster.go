@@ -0,0 +1,317 @@
+package cddadb
+
+import (
+	"encoding/json"
+	"math"
+)
+
+// Monster is a resolved monster along with the values the game works out
+// from it when it loads.
+type Monster struct {
+	ID             string           `json:"id"`
+	Name           string           `json:"name"`
+	Description    string           `json:"description"`
+	Species        []string         `json:"species"`
+	Faction        string           `json:"default_faction"`
+	Size           string           `json:"size"`
+	Material       []string         `json:"material"`
+	Flags          []string         `json:"flags"`
+	HP             int              `json:"hp"`
+	Speed          int              `json:"speed"`
+	Aggression     int              `json:"aggression"`
+	Morale         int              `json:"morale"`
+	AttackCost     int              `json:"attack_cost"`
+	MeleeSkill     int              `json:"melee_skill"`
+	MeleeDice      int              `json:"melee_dice"`
+	MeleeDiceSides int              `json:"melee_dice_sides"`
+	MeleeCut       int              `json:"melee_cut"`
+	Dodge          int              `json:"dodge"`
+	VisionDay      int              `json:"vision_day"`
+	VisionNight    int              `json:"vision_night"`
+	Armor          MonsterArmor     `json:"armor"`
+	BaseDifficulty int              `json:"base_difficulty"`
+	Difficulty     int              `json:"difficulty"`
+	SpecialAttacks []*SpecialAttack `json:"special_attacks"`
+	DeathFunction  []string         `json:"death_function"`
+
+	SpeciesDetail []*Species          `json:"species_detail,omitempty"`
+	FactionDetail *MonsterFaction     `json:"faction_detail,omitempty"`
+	DeathDrops    *ItemGroupExpansion `json:"death_drops,omitempty"`
+	Translation   *Localized          `json:"translation,omitempty"`
+
+	deathDrops json.RawMessage
+	emitFields int
+}
+
+// MonsterArmor is the armor a monster has against each type of damage.
+type MonsterArmor struct {
+	Bash float64 `json:"bash"`
+	Cut  float64 `json:"cut"`
+	Stab float64 `json:"stab"`
+	Acid float64 `json:"acid"`
+	Fire float64 `json:"fire"`
+}
+
+// SpecialAttack is one of a monster's special attacks, written in the data
+// either as an [id, cooldown] pair or as an object describing the attack.
+type SpecialAttack struct {
+	ID       string          `json:"id"`
+	Cooldown int             `json:"cooldown"`
+	Details  json.RawMessage `json:"details,omitempty"`
+}
+
+// MonsterSummary is the short form of a monster used in lists.
+type MonsterSummary struct {
+	ID         string   `json:"id"`
+	Name       string   `json:"name"`
+	Species    []string `json:"species"`
+	Faction    string   `json:"default_faction"`
+	HP         int      `json:"hp"`
+	Speed      int      `json:"speed"`
+	Difficulty int      `json:"difficulty"`
+}
+
+// Species is a species of monster and what angers, scares or placates it.
+type Species struct {
+	ID              string   `json:"id"`
+	Description     string   `json:"description"`
+	Flags           []string `json:"flags"`
+	AngerTriggers   []string `json:"anger_triggers"`
+	FearTriggers    []string `json:"fear_triggers"`
+	PlacateTriggers []string `json:"placate_triggers"`
+}
+
+// MonsterFaction is how a faction of monsters gets along with the others.
+type MonsterFaction struct {
+	Name        string   `json:"name"`
+	BaseFaction string   `json:"base_faction"`
+	ByMood      []string `json:"by_mood"`
+	Neutral     []string `json:"neutral"`
+	Friendly    []string `json:"friendly"`
+	Hate        []string `json:"hate"`
+}
+
+type monsterJSON struct {
+	ID             string            `json:"id"`
+	Name           Translation       `json:"name"`
+	Description    string            `json:"description"`
+	Species        Tags              `json:"species"`
+	DefaultFaction string            `json:"default_faction"`
+	Size           string            `json:"size"`
+	Material       Tags              `json:"material"`
+	Flags          Tags              `json:"flags"`
+	HP             int               `json:"hp"`
+	Speed          int               `json:"speed"`
+	Aggression     int               `json:"aggression"`
+	Morale         int               `json:"morale"`
+	AttackCost     *int              `json:"attack_cost"`
+	MeleeSkill     int               `json:"melee_skill"`
+	MeleeDice      int               `json:"melee_dice"`
+	MeleeDiceSides int               `json:"melee_dice_sides"`
+	MeleeCut       int               `json:"melee_cut"`
+	MeleeDamage    DamageInstance    `json:"melee_damage"`
+	Dodge          int               `json:"dodge"`
+	ArmorBash      float64           `json:"armor_bash"`
+	ArmorCut       float64           `json:"armor_cut"`
+	ArmorStab      *float64          `json:"armor_stab"`
+	ArmorAcid      *float64          `json:"armor_acid"`
+	ArmorFire      float64           `json:"armor_fire"`
+	VisionDay      *int              `json:"vision_day"`
+	VisionNight    *int              `json:"vision_night"`
+	Diff           int               `json:"diff"`
+	SpecialAttacks []json.RawMessage `json:"special_attacks"`
+	DeathFunction  Tags              `json:"death_function"`
+	DeathDrops     json.RawMessage   `json:"death_drops"`
+	EmitFields     []json.RawMessage `json:"emit_fields"`
+}
+
+// ParseMonster reads a resolved MONSTER definition, filling in the defaults
+// the game uses for members left out.
+func ParseMonster(resolved []byte) (*Monster, error) {
+	var j monsterJSON
+	if err := json.Unmarshal(resolved, &j); err != nil {
+		return nil, err
+	}
+
+	m := &Monster{
+		ID:             j.ID,
+		Name:           j.Name.Str,
+		Description:    j.Description,
+		Species:        nonNil(j.Species),
+		Faction:        j.DefaultFaction,
+		Size:           j.Size,
+		Material:       nonNil(j.Material),
+		Flags:          nonNil(j.Flags),
+		HP:             j.HP,
+		Speed:          j.Speed,
+		Aggression:     j.Aggression,
+		Morale:         j.Morale,
+		AttackCost:     100,
+		MeleeSkill:     j.MeleeSkill,
+		MeleeDice:      j.MeleeDice,
+		MeleeDiceSides: j.MeleeDiceSides,
+		MeleeCut:       j.MeleeCut,
+		Dodge:          j.Dodge,
+		VisionDay:      40,
+		VisionNight:    1,
+		BaseDifficulty: j.Diff,
+		SpecialAttacks: []*SpecialAttack{},
+		DeathFunction:  nonNil(j.DeathFunction),
+		deathDrops:     j.DeathDrops,
+		emitFields:     len(j.EmitFields),
+	}
+	if j.AttackCost != nil {
+		m.AttackCost = *j.AttackCost
+	}
+	if j.VisionDay != nil {
+		m.VisionDay = *j.VisionDay
+	}
+	if j.VisionNight != nil {
+		m.VisionNight = *j.VisionNight
+	}
+	// Like the game, melee_damage replaces melee_cut when it is given, so a
+	// monster whose melee_damage has no cut does no cutting damage.
+	if len(j.MeleeDamage) > 0 {
+		m.MeleeCut = 0
+		for _, d := range j.MeleeDamage {
+			if d.DamageType == "cut" {
+				m.MeleeCut += int(d.Amount)
+			}
+		}
+	}
+
+	m.Armor = MonsterArmor{
+		Bash: j.ArmorBash,
+		Cut:  j.ArmorCut,
+		Stab: math.Trunc(0.8 * j.ArmorCut),
+		Acid: math.Trunc(0.5 * j.ArmorCut),
+		Fire: j.ArmorFire,
+	}
+	if j.ArmorStab != nil {
+		m.Armor.Stab = *j.ArmorStab
+	}
+	if j.ArmorAcid != nil {
+		m.Armor.Acid = *j.ArmorAcid
+	}
+
+	for _, raw := range j.SpecialAttacks {
+		m.SpecialAttacks = append(m.SpecialAttacks, parseSpecialAttack(raw))
+	}
+
+	m.Difficulty = m.difficulty()
+	return m, nil
+}
+
+func parseSpecialAttack(raw json.RawMessage) *SpecialAttack {
+	var pair []interface{}
+	if err := json.Unmarshal(raw, &pair); err == nil {
+		a := &SpecialAttack{}
+		if len(pair) > 0 {
+			a.ID, _ = pair[0].(string)
+		}
+		if len(pair) > 1 {
+			cooldown, _ := pair[1].(float64)
+			a.Cooldown = int(cooldown)
+		}
+		return a
+	}
+
+	var o struct {
+		ID       string `json:"id"`
+		Type     string `json:"type"`
+		Cooldown int    `json:"cooldown"`
+	}
+	json.Unmarshal(raw, &o)
+	a := &SpecialAttack{ID: o.ID, Cooldown: o.Cooldown, Details: raw}
+	if a.ID == "" {
+		a.ID = o.Type
+	}
+	return a
+}
+
+// difficulty is worked out the way MonsterGenerator::finalize_mtypes does:
+// how hard the monster hits and how hard it is to hit, plus its base
+// difficulty, scaled by how tough, fast and perceptive it is. The game keeps
+// the first part in an int before scaling it, so it is truncated here too.
+func (m *Monster) difficulty() int {
+	d := int(float64(m.MeleeSkill+1)*float64(m.MeleeDice)*float64(m.MeleeCut+m.MeleeDiceSides)*0.04 +
+		float64(m.Dodge+1)*(3+m.Armor.Bash+m.Armor.Cut)*0.04 +
+		float64(m.BaseDifficulty+len(m.SpecialAttacks)+8*m.emitFields))
+	scale := float64(m.HP+m.Speed-m.AttackCost)*0.01 + float64(m.Morale+m.Aggression)*0.1*0.01 +
+		float64(m.VisionDay+2*m.VisionNight)*0.01
+	return int(float64(d) * scale)
+}
+
+// Summary is the short form of the monster.
+func (m *Monster) Summary() *MonsterSummary {
+	return &MonsterSummary{
+		ID:         m.ID,
+		Name:       m.Name,
+		Species:    m.Species,
+		Faction:    m.Faction,
+		HP:         m.HP,
+		Speed:      m.Speed,
+		Difficulty: m.Difficulty,
+	}
+}
+
+// expandDeathDrops fills in DeathDrops from the item group the monster drops,
+// named or written inline.
+func (m *Monster) expandDeathDrops(x *ItemGroupExpander) {
+	m.DeathDrops = x.ExpandInline(m.deathDrops, m.ID+"_death_drops", "distribution")
+}
+
+func nonNil(t Tags) []string {
+	if t == nil {
+		return []string{}
+	}
+	return t
+}
+
+type speciesJSON struct {
+	ID              string `json:"id"`
+	Description     string `json:"description"`
+	Flags           Tags   `json:"flags"`
+	AngerTriggers   Tags   `json:"anger_triggers"`
+	FearTriggers    Tags   `json:"fear_triggers"`
+	PlacateTriggers Tags   `json:"placate_triggers"`
+}
+
+func parseSpecies(resolved []byte) (*Species, error) {
+	var j speciesJSON
+	if err := json.Unmarshal(resolved, &j); err != nil {
+		return nil, err
+	}
+	return &Species{
+		ID:              j.ID,
+		Description:     j.Description,
+		Flags:           nonNil(j.Flags),
+		AngerTriggers:   nonNil(j.AngerTriggers),
+		FearTriggers:    nonNil(j.FearTriggers),
+		PlacateTriggers: nonNil(j.PlacateTriggers),
+	}, nil
+}
+
+type monsterFactionJSON struct {
+	Name        string `json:"name"`
+	BaseFaction string `json:"base_faction"`
+	ByMood      Tags   `json:"by_mood"`
+	Neutral     Tags   `json:"neutral"`
+	Friendly    Tags   `json:"friendly"`
+	Hate        Tags   `json:"hate"`
+}
+
+func parseMonsterFaction(resolved []byte) (*MonsterFaction, error) {
+	var j monsterFactionJSON
+	if err := json.Unmarshal(resolved, &j); err != nil {
+		return nil, err
+	}
+	return &MonsterFaction{
+		Name:        j.Name,
+		BaseFaction: j.BaseFaction,
+		ByMood:      nonNil(j.ByMood),
+		Neutral:     nonNil(j.Neutral),
+		Friendly:    nonNil(j.Friendly),
+		Hate:        nonNil(j.Hate),
+	}, nil
+}
@@ -0,0 +1,116 @@
+package cddadb
+
+import "encoding/json"
+
+// MonsterGroup is a group of monsters that spawn together, with the chance
+// of each, and the places in the data that spawn the group.
+type MonsterGroup struct {
+	ID        string               `json:"id"`
+	Default   string               `json:"default"`
+	IsSafe    bool                 `json:"is_safe"`
+	IsAnimal  bool                 `json:"is_animal"`
+	Monsters  []*MonsterGroupEntry `json:"monsters"`
+	Locations []*ObjectRef         `json:"locations"`
+}
+
+// MonsterGroupEntry is one monster, or nested group, of a monster group.
+// Chance is its share of the group's 1000 frequency points, the default
+// monster taking whatever is left over.
+type MonsterGroupEntry struct {
+	Monster        string   `json:"monster,omitempty"`
+	Group          string   `json:"group,omitempty"`
+	Freq           int      `json:"freq"`
+	Chance         float64  `json:"chance"`
+	CostMultiplier int      `json:"cost_multiplier"`
+	PackSize       [2]int   `json:"pack_size"`
+	Starts         int      `json:"starts,omitempty"`
+	Ends           int      `json:"ends,omitempty"`
+	Conditions     []string `json:"conditions"`
+}
+
+// ObjectRef points at a game object by type and id, with the file it was
+// read from for objects that have no id.
+type ObjectRef struct {
+	Type   string `json:"type" db:"type"`
+	ID     string `json:"id" db:"id"`
+	Source string `json:"source" db:"source"`
+}
+
+// monsterGroupFreqTotal is the frequency the entries of a monster group
+// share out between them.
+const monsterGroupFreqTotal = 1000
+
+type monsterGroupJSON struct {
+	Name     string `json:"name"`
+	Default  string `json:"default"`
+	IsSafe   bool   `json:"is_safe"`
+	IsAnimal bool   `json:"is_animal"`
+	Monsters []struct {
+		Monster        string          `json:"monster"`
+		Group          string          `json:"group"`
+		Freq           int             `json:"freq"`
+		CostMultiplier *int            `json:"cost_multiplier"`
+		PackSize       json.RawMessage `json:"pack_size"`
+		Starts         int             `json:"starts"`
+		Ends           int             `json:"ends"`
+		Conditions     Tags            `json:"conditions"`
+	} `json:"monsters"`
+}
+
+// ParseMonsterGroup reads a resolved monstergroup definition.
+func ParseMonsterGroup(resolved []byte) (*MonsterGroup, error) {
+	var j monsterGroupJSON
+	if err := json.Unmarshal(resolved, &j); err != nil {
+		return nil, err
+	}
+
+	g := &MonsterGroup{
+		ID:        j.Name,
+		Default:   j.Default,
+		IsSafe:    j.IsSafe,
+		IsAnimal:  j.IsAnimal,
+		Monsters:  []*MonsterGroupEntry{},
+		Locations: []*ObjectRef{},
+	}
+
+	total := 0
+	for _, m := range j.Monsters {
+		e := &MonsterGroupEntry{
+			Monster:        m.Monster,
+			Group:          m.Group,
+			Freq:           m.Freq,
+			Chance:         float64(m.Freq) / monsterGroupFreqTotal,
+			CostMultiplier: 1,
+			PackSize:       [2]int{1, 1},
+			Starts:         m.Starts,
+			Ends:           m.Ends,
+			Conditions:     nonNil(m.Conditions),
+		}
+		if m.CostMultiplier != nil {
+			e.CostMultiplier = *m.CostMultiplier
+		}
+		var n int
+		var r []int
+		if err := json.Unmarshal(m.PackSize, &n); err == nil {
+			e.PackSize = [2]int{n, n}
+		} else if err := json.Unmarshal(m.PackSize, &r); err == nil && len(r) == 2 {
+			e.PackSize = [2]int{r[0], r[1]}
+		}
+		total += m.Freq
+		g.Monsters = append(g.Monsters, e)
+	}
+
+	if g.Default != "" && total < monsterGroupFreqTotal {
+		left := monsterGroupFreqTotal - total
+		g.Monsters = append(g.Monsters, &MonsterGroupEntry{
+			Monster:        g.Default,
+			Freq:           left,
+			Chance:         float64(left) / monsterGroupFreqTotal,
+			CostMultiplier: 1,
+			PackSize:       [2]int{1, 1},
+			Conditions:     []string{},
+		})
+	}
+
+	return g, nil
+}
@@ -0,0 +1,338 @@
+package cddadb
+
+import (
+	"encoding/json"
+	"sort"
+)
+
+// Mutation is a resolved mutation. A mutation needs one of Prereqs and one of
+// Prereqs2 when they are given, and one of ThreshReq once past a threshold.
+// ChangesTo lists what it can grow into, replacing it, and LeadsTo what it
+// can add alongside it.
+type Mutation struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Points      int      `json:"points"`
+	Category    []string `json:"category"`
+	Types       []string `json:"types"`
+	Threshold   bool     `json:"threshold"`
+	Profession  bool     `json:"profession"`
+	Purifiable  bool     `json:"purifiable"`
+	Valid       bool     `json:"valid"`
+	Prereqs     []string `json:"prereqs"`
+	Prereqs2    []string `json:"prereqs2"`
+	ThreshReq   []string `json:"threshreq"`
+	Cancels     []string `json:"cancels"`
+	ChangesTo   []string `json:"changes_to"`
+	LeadsTo     []string `json:"leads_to"`
+}
+
+type mutationJSON struct {
+	ID          string      `json:"id"`
+	Name        Translation `json:"name"`
+	Description string      `json:"description"`
+	Points      int         `json:"points"`
+	Category    Tags        `json:"category"`
+	Types       Tags        `json:"types"`
+	Threshold   bool        `json:"threshold"`
+	Profession  bool        `json:"profession"`
+	Purifiable  *bool       `json:"purifiable"`
+	Valid       *bool       `json:"valid"`
+	Prereqs     Tags        `json:"prereqs"`
+	Prereqs2    Tags        `json:"prereqs2"`
+	ThreshReq   Tags        `json:"threshreq"`
+	Cancels     Tags        `json:"cancels"`
+	ChangesTo   Tags        `json:"changes_to"`
+	LeadsTo     Tags        `json:"leads_to"`
+}
+
+// ParseMutation reads a resolved mutation definition. Mutations are valid and
+// purifiable unless they say otherwise.
+func ParseMutation(resolved []byte) (*Mutation, error) {
+	var j mutationJSON
+	if err := json.Unmarshal(resolved, &j); err != nil {
+		return nil, err
+	}
+	m := &Mutation{
+		ID:          j.ID,
+		Name:        j.Name.Str,
+		Description: j.Description,
+		Points:      j.Points,
+		Category:    nonNil(j.Category),
+		Types:       nonNil(j.Types),
+		Threshold:   j.Threshold,
+		Profession:  j.Profession,
+		Purifiable:  j.Purifiable == nil || *j.Purifiable,
+		Valid:       j.Valid == nil || *j.Valid,
+		Prereqs:     nonNil(j.Prereqs),
+		Prereqs2:    nonNil(j.Prereqs2),
+		ThreshReq:   nonNil(j.ThreshReq),
+		Cancels:     nonNil(j.Cancels),
+		ChangesTo:   nonNil(j.ChangesTo),
+		LeadsTo:     nonNil(j.LeadsTo),
+	}
+	return m, nil
+}
+
+// MutationCategory is a mutation category and the threshold mutation that
+// commits a character to it.
+type MutationCategory struct {
+	ID             string `json:"id"`
+	Name           string `json:"name"`
+	ThresholdMut   string `json:"threshold_mut"`
+	MutagenMessage string `json:"mutagen_message,omitempty"`
+}
+
+type mutationCategoryJSON struct {
+	ID             string      `json:"id"`
+	Name           Translation `json:"name"`
+	ThresholdMut   string      `json:"threshold_mut"`
+	MutagenMessage string      `json:"mutagen_message"`
+}
+
+func parseMutationCategory(resolved []byte) (*MutationCategory, error) {
+	var j mutationCategoryJSON
+	if err := json.Unmarshal(resolved, &j); err != nil {
+		return nil, err
+	}
+	return &MutationCategory{
+		ID:             j.ID,
+		Name:           j.Name.Str,
+		ThresholdMut:   j.ThresholdMut,
+		MutagenMessage: j.MutagenMessage,
+	}, nil
+}
+
+// MutationGraph is the part of the mutation tree around one mutation: the
+// mutations leading up to it, the ones it leads on to, and the ones any of
+// those cancel.
+type MutationGraph struct {
+	Root  string          `json:"root"`
+	Nodes []*MutationNode `json:"nodes"`
+	Edges []*MutationEdge `json:"edges"`
+}
+
+// MutationNode is a mutation in a graph. Unknown marks one that is referred
+// to but not defined.
+type MutationNode struct {
+	ID        string   `json:"id"`
+	Name      string   `json:"name"`
+	Points    int      `json:"points"`
+	Category  []string `json:"category"`
+	Threshold bool     `json:"threshold"`
+	Unknown   bool     `json:"unknown,omitempty"`
+}
+
+// MutationEdge joins two mutations. Requirement kinds, prereq, prereqs2 and
+// threshreq, point from what is needed to what needs it; changes_to,
+// leads_to and cancels point the way the data writes them.
+type MutationEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Kind string `json:"kind"`
+}
+
+// MutationTree walks the mutations keyed by id from root, up through what
+// each one needs or grows out of and down through what needs it or grows
+// out of it.
+func MutationTree(root string, mutations map[string]*Mutation) *MutationGraph {
+	// needed lists, for every mutation, the mutations that require it, and
+	// grownFrom the ones that change into it.
+	type link struct {
+		to   string
+		kind string
+	}
+	ids := make([]string, 0, len(mutations))
+	for id := range mutations {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	needed := make(map[string][]link)
+	grownFrom := make(map[string][]string)
+	for _, id := range ids {
+		m := mutations[id]
+		for _, p := range m.Prereqs {
+			needed[p] = append(needed[p], link{m.ID, "prereq"})
+		}
+		for _, p := range m.Prereqs2 {
+			needed[p] = append(needed[p], link{m.ID, "prereqs2"})
+		}
+		for _, p := range m.ThreshReq {
+			needed[p] = append(needed[p], link{m.ID, "threshreq"})
+		}
+		for _, c := range m.ChangesTo {
+			grownFrom[c] = append(grownFrom[c], m.ID)
+		}
+	}
+
+	g := &MutationGraph{Root: root, Nodes: []*MutationNode{}, Edges: []*MutationEdge{}}
+	nodes := make(map[string]bool)
+	edges := make(map[MutationEdge]bool)
+	addNode := func(id string) {
+		if nodes[id] {
+			return
+		}
+		nodes[id] = true
+		n := &MutationNode{ID: id, Category: []string{}}
+		if m, ok := mutations[id]; ok {
+			n.Name, n.Points, n.Category, n.Threshold = m.Name, m.Points, m.Category, m.Threshold
+		} else {
+			n.Unknown = true
+		}
+		g.Nodes = append(g.Nodes, n)
+	}
+	addEdge := func(from, to, kind string) {
+		e := MutationEdge{From: from, To: to, Kind: kind}
+		if !edges[e] {
+			edges[e] = true
+			g.Edges = append(g.Edges, &e)
+		}
+	}
+
+	// Walk up from the root.
+	up := []string{root}
+	seenUp := map[string]bool{root: true}
+	for len(up) > 0 {
+		id := up[0]
+		up = up[1:]
+		addNode(id)
+		m, ok := mutations[id]
+		if !ok {
+			continue
+		}
+		parents := []MutationEdge{}
+		for _, p := range m.Prereqs {
+			parents = append(parents, MutationEdge{From: p, To: id, Kind: "prereq"})
+		}
+		for _, p := range m.Prereqs2 {
+			parents = append(parents, MutationEdge{From: p, To: id, Kind: "prereqs2"})
+		}
+		for _, p := range m.ThreshReq {
+			parents = append(parents, MutationEdge{From: p, To: id, Kind: "threshreq"})
+		}
+		for _, p := range grownFrom[id] {
+			parents = append(parents, MutationEdge{From: p, To: id, Kind: "changes_to"})
+		}
+		for _, e := range parents {
+			addEdge(e.From, e.To, e.Kind)
+			if !seenUp[e.From] {
+				seenUp[e.From] = true
+				up = append(up, e.From)
+			}
+		}
+	}
+
+	// Walk down from the root.
+	down := []string{root}
+	seenDown := map[string]bool{root: true}
+	for len(down) > 0 {
+		id := down[0]
+		down = down[1:]
+		addNode(id)
+		children := []MutationEdge{}
+		for _, l := range needed[id] {
+			children = append(children, MutationEdge{From: id, To: l.to, Kind: l.kind})
+		}
+		if m, ok := mutations[id]; ok {
+			for _, c := range m.ChangesTo {
+				children = append(children, MutationEdge{From: id, To: c, Kind: "changes_to"})
+			}
+			for _, c := range m.LeadsTo {
+				children = append(children, MutationEdge{From: id, To: c, Kind: "leads_to"})
+			}
+		}
+		for _, e := range children {
+			addEdge(e.From, e.To, e.Kind)
+			if !seenDown[e.To] {
+				seenDown[e.To] = true
+				down = append(down, e.To)
+			}
+		}
+	}
+
+	// Cancels and thresholds only reach one step out, so a graph doesn't grow
+	// to cover every mutation that conflicts with something in it or shares
+	// its threshold.
+	in := make([]string, 0, len(nodes))
+	for id := range nodes {
+		in = append(in, id)
+	}
+	sort.Strings(in)
+	for _, id := range in {
+		if m, ok := mutations[id]; ok {
+			for _, c := range m.Cancels {
+				addNode(c)
+				addEdge(id, c, "cancels")
+			}
+			for _, t := range m.ThreshReq {
+				addNode(t)
+				addEdge(t, id, "threshreq")
+			}
+		}
+	}
+
+	sort.Slice(g.Nodes, func(i, j int) bool { return g.Nodes[i].ID < g.Nodes[j].ID })
+	return g
+}
+
+// Bionic is a resolved bionic with the CBM item that installs it. Capacity is
+// the power it stores, in millijoules.
+type Bionic struct {
+	ID                string         `json:"id" db:"id"`
+	Name              string         `json:"name"`
+	Description       string         `json:"description"`
+	Capacity          *int64         `json:"capacity" db:"capacity"`
+	Flags             []string       `json:"flags"`
+	OccupiedBodyparts map[string]int `json:"occupied_bodyparts"`
+	IncludedBionics   []string       `json:"included_bionics"`
+	CanceledMutations []string       `json:"canceled_mutations"`
+	Item              *BionicItem    `json:"item"`
+}
+
+// BionicItem is the CBM that installs a bionic, Difficulty being how hard it
+// is to install.
+type BionicItem struct {
+	ID         string `json:"id" db:"id"`
+	Name       string `json:"name" db:"name"`
+	Difficulty int    `json:"difficulty" db:"difficulty"`
+	IsUpgrade  bool   `json:"is_upgrade" db:"is_upgrade"`
+}
+
+type bionicJSON struct {
+	ID                string            `json:"id"`
+	Name              Translation       `json:"name"`
+	Description       string            `json:"description"`
+	Flags             Tags              `json:"flags"`
+	OccupiedBodyparts []json.RawMessage `json:"occupied_bodyparts"`
+	IncludedBionics   Tags              `json:"included_bionics"`
+	CanceledMutations Tags              `json:"canceled_mutations"`
+}
+
+// ParseBionic reads a resolved bionic definition. Capacity and Item are
+// filled in from the database.
+func ParseBionic(resolved []byte) (*Bionic, error) {
+	var j bionicJSON
+	if err := json.Unmarshal(resolved, &j); err != nil {
+		return nil, err
+	}
+	b := &Bionic{
+		ID:                j.ID,
+		Name:              j.Name.Str,
+		Description:       j.Description,
+		Flags:             nonNil(j.Flags),
+		OccupiedBodyparts: make(map[string]int),
+		IncludedBionics:   nonNil(j.IncludedBionics),
+		CanceledMutations: nonNil(j.CanceledMutations),
+	}
+	for _, raw := range j.OccupiedBodyparts {
+		var pair []interface{}
+		if err := json.Unmarshal(raw, &pair); err != nil || len(pair) != 2 {
+			continue
+		}
+		part, _ := pair[0].(string)
+		slots, _ := pair[1].(float64)
+		b.OccupiedBodyparts[part] = int(slots)
+	}
+	return b, nil
+}
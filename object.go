@@ -0,0 +1,52 @@
+package cddadb
+
+import "fmt"
+
+// JSON is a jsonb column passed through to API responses untouched.
+type JSON []byte
+
+func (j *JSON) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case []byte:
+		*j = append((*j)[0:0], v...)
+	case string:
+		*j = JSON(v)
+	case nil:
+		*j = nil
+	default:
+		return fmt.Errorf("cannot scan %T into JSON", src)
+	}
+	return nil
+}
+
+func (j JSON) MarshalJSON() ([]byte, error) {
+	if len(j) == 0 {
+		return []byte("null"), nil
+	}
+	return j, nil
+}
+
+type GameObject struct {
+	ID       string `json:"id" db:"id"`
+	Abstract string `json:"abstract" db:"abstract"`
+	Type     string `json:"type" db:"type"`
+	Source   string `json:"source" db:"source"`
+	Mod      string `json:"mod" db:"mod"`
+	Raw      JSON   `json:"raw" db:"raw"`
+	Resolved JSON   `json:"resolved" db:"resolved"`
+}
+
+type TypeCount struct {
+	Type  string `json:"type" db:"type"`
+	Count int    `json:"count" db:"count"`
+}
+
+// Reference is a place where an object's definition names another object.
+// Path is where in the definition the id is written.
+type Reference struct {
+	Type   string `json:"type" db:"object_type"`
+	ID     string `json:"id" db:"object_id"`
+	Source string `json:"source" db:"source"`
+	Mod    string `json:"mod" db:"mod"`
+	Path   string `json:"path" db:"path"`
+}
@@ -0,0 +1,237 @@
+package cddadb
+
+import (
+	"encoding/json"
+	"math"
+	"sort"
+	"strings"
+)
+
+const (
+	defaultArmorLimit = 20
+	maxArmorLimit     = 100
+)
+
+// Material is a resolved material and how well it resists each kind of
+// damage.
+type Material struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	BashResist int    `json:"bash_resist"`
+	CutResist  int    `json:"cut_resist"`
+	AcidResist int    `json:"acid_resist"`
+	FireResist int    `json:"fire_resist"`
+	ElecResist int    `json:"elec_resist"`
+	ChipResist int    `json:"chip_resist"`
+	Density    int    `json:"density"`
+}
+
+type materialJSON struct {
+	ID         string      `json:"id"`
+	Ident      string      `json:"ident"`
+	Name       Translation `json:"name"`
+	BashResist int         `json:"bash_resist"`
+	CutResist  int         `json:"cut_resist"`
+	AcidResist int         `json:"acid_resist"`
+	FireResist int         `json:"fire_resist"`
+	ElecResist int         `json:"elec_resist"`
+	ChipResist int         `json:"chip_resist"`
+	Density    int         `json:"density"`
+}
+
+// ParseMaterial reads a resolved material definition, which older data
+// identifies by ident.
+func ParseMaterial(resolved []byte) (*Material, error) {
+	var j materialJSON
+	if err := json.Unmarshal(resolved, &j); err != nil {
+		return nil, err
+	}
+	m := &Material{
+		ID:         j.ID,
+		Name:       j.Name.Str,
+		BashResist: j.BashResist,
+		CutResist:  j.CutResist,
+		AcidResist: j.AcidResist,
+		FireResist: j.FireResist,
+		ElecResist: j.ElecResist,
+		ChipResist: j.ChipResist,
+		Density:    j.Density,
+	}
+	if m.ID == "" {
+		m.ID = j.Ident
+	}
+	return m, nil
+}
+
+// Protection is what a piece of armor protects against when undamaged, worked
+// out the way item::bash_resist and its siblings do.
+type Protection struct {
+	Item                    string                `json:"item"`
+	Name                    string                `json:"name"`
+	Materials               []string              `json:"materials"`
+	UnknownMaterials        []string              `json:"unknown_materials"`
+	Thickness               int                   `json:"thickness"`
+	EnvironmentalProtection int                   `json:"environmental_protection"`
+	Warmth                  int                   `json:"warmth"`
+	Sided                   bool                  `json:"sided"`
+	Bash                    int                   `json:"bash"`
+	Cut                     int                   `json:"cut"`
+	Acid                    int                   `json:"acid"`
+	Fire                    int                   `json:"fire"`
+	Elec                    int                   `json:"elec"`
+	BodyParts               []*BodyPartProtection `json:"body_parts"`
+}
+
+// BodyPartProtection is the coverage and encumbrance of armor on one body
+// part. FittedEncumbrance is what it drops to once the armor is fitted, for
+// armor that can be.
+type BodyPartProtection struct {
+	BodyPart          string `json:"body_part"`
+	Coverage          int    `json:"coverage"`
+	Encumbrance       int    `json:"encumbrance"`
+	FittedEncumbrance *int   `json:"fitted_encumbrance,omitempty"`
+}
+
+// ArmorProtection works out the protection of an item from its armor slot
+// and materials, nil for an item that isn't worn. Resistance is the mean of
+// the item's materials, multiplied by its thickness for bash and cut and
+// scaled down by environmental protection below 10 for acid, fire and
+// electricity.
+func ArmorProtection(t *ItemType, materials map[string]*Material) *Protection {
+	a := t.ArmorSlot
+	if a == nil || len(a.Covers) == 0 {
+		return nil
+	}
+
+	p := &Protection{
+		Item:                    t.ID,
+		Name:                    t.Name.Str,
+		Materials:               nonNil(t.Material),
+		UnknownMaterials:        []string{},
+		Thickness:               a.Thickness,
+		EnvironmentalProtection: a.EnvironmentalProtection,
+		Warmth:                  a.Warmth,
+		Sided:                   a.Sided,
+		BodyParts:               []*BodyPartProtection{},
+	}
+
+	var bash, cut, acid, fire, elec float64
+	known := 0
+	for _, id := range t.Material {
+		m, ok := materials[id]
+		if !ok {
+			p.UnknownMaterials = append(p.UnknownMaterials, id)
+			continue
+		}
+		known++
+		bash += float64(m.BashResist)
+		cut += float64(m.CutResist)
+		acid += float64(m.AcidResist)
+		fire += float64(m.FireResist)
+		elec += float64(m.ElecResist)
+	}
+	if known > 0 {
+		n := float64(known)
+		bash, cut, acid, fire, elec = bash/n, cut/n, acid/n, fire/n, elec/n
+	}
+
+	thickness := float64(maxInt(1, a.Thickness))
+	env := 1.0
+	if a.EnvironmentalProtection < 10 {
+		env = float64(a.EnvironmentalProtection) / 10
+	}
+	p.Bash = int(math.Round(bash * thickness))
+	p.Cut = int(math.Round(cut * thickness))
+	p.Acid = int(math.Round(acid * env))
+	p.Fire = int(math.Round(fire * env))
+	p.Elec = int(math.Round(elec * env))
+
+	variable := hasTag(t.Flags, "VARSIZE") && !hasTag(t.Flags, "FIT")
+	for _, part := range a.Covers {
+		b := &BodyPartProtection{BodyPart: part, Coverage: a.Coverage, Encumbrance: a.Encumbrance}
+		if variable {
+			fitted := maxInt(a.Encumbrance/2, a.Encumbrance-10)
+			b.FittedEncumbrance = &fitted
+		}
+		p.BodyParts = append(p.BodyParts, b)
+	}
+	return p
+}
+
+// Covers returns the protection of the armor on a body part, nil if it
+// doesn't cover it.
+func (p *Protection) Covers(bodyPart string) *BodyPartProtection {
+	for _, b := range p.BodyParts {
+		if strings.EqualFold(b.BodyPart, bodyPart) {
+			return b
+		}
+	}
+	return nil
+}
+
+// ArmorRanking is a piece of armor compared with others on one body part.
+// Score is its resistance to the damage compared, weighted by how much of
+// the body part it covers.
+type ArmorRanking struct {
+	Item        string  `json:"item"`
+	Name        string  `json:"name"`
+	Resistance  int     `json:"resistance"`
+	Coverage    int     `json:"coverage"`
+	Encumbrance int     `json:"encumbrance"`
+	Score       float64 `json:"score"`
+}
+
+// armorDamageTypes are the resistances armor can be ranked by.
+var armorDamageTypes = map[string]func(p *Protection) int{
+	"bash": func(p *Protection) int { return p.Bash },
+	"cut":  func(p *Protection) int { return p.Cut },
+	"acid": func(p *Protection) int { return p.Acid },
+	"fire": func(p *Protection) int { return p.Fire },
+	"elec": func(p *Protection) int { return p.Elec },
+}
+
+// RankArmor orders the armor that covers a body part by its resistance to
+// a damage type weighted by coverage, least encumbering first among equals.
+func RankArmor(armor []*Protection, bodyPart, damage string) ([]*ArmorRanking, error) {
+	resist, ok := armorDamageTypes[damage]
+	if !ok {
+		return nil, &BadRequestError{Message: "unknown damage type " + damage}
+	}
+
+	ranks := []*ArmorRanking{}
+	for _, p := range armor {
+		b := p.Covers(bodyPart)
+		if b == nil {
+			continue
+		}
+		r := resist(p)
+		ranks = append(ranks, &ArmorRanking{
+			Item:        p.Item,
+			Name:        p.Name,
+			Resistance:  r,
+			Coverage:    b.Coverage,
+			Encumbrance: b.Encumbrance,
+			Score:       float64(r) * float64(b.Coverage) / 100,
+		})
+	}
+	sort.Slice(ranks, func(i, j int) bool {
+		a, b := ranks[i], ranks[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if a.Encumbrance != b.Encumbrance {
+			return a.Encumbrance < b.Encumbrance
+		}
+		return a.Item < b.Item
+	})
+	return ranks, nil
+}
+
+func hasTag(tags Tags, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,68 @@
+package cddadb
+
+// Recipe is a recipe or an uncraft with the requirements it uses already
+// expanded. Tools and Components are lists of groups, any one alternative of
+// a group being enough. Makes is how many of Result one batch makes, more
+// than one for a result_mult or an item counted by charges. Name is the name
+// of the result.
+type Recipe struct {
+	Type        string             `json:"type" db:"recipe_type"`
+	ID          string             `json:"id" db:"recipe_id"`
+	Result      string             `json:"result" db:"result"`
+	Name        string             `json:"name" db:"name"`
+	Makes       int                `json:"makes" db:"makes"`
+	Category    string             `json:"category" db:"category"`
+	Subcategory string             `json:"subcategory" db:"subcategory"`
+	SkillUsed   string             `json:"skill_used" db:"skill_used"`
+	Difficulty  int                `json:"difficulty" db:"difficulty"`
+	Time        *int64             `json:"time" db:"time"`
+	Reversible  bool               `json:"reversible" db:"reversible"`
+	Skills      []*RecipeSkill     `json:"skills_required"`
+	Qualities   [][]*RecipeQuality `json:"qualities"`
+	Tools       [][]*RecipeItem    `json:"tools"`
+	Components  [][]*RecipeItem    `json:"components"`
+}
+
+// RecipeItem is one alternative of a tool or component group. For a tool
+// Count is the charges used, -1 when it uses none. Via names the requirement
+// the alternative came from, if any.
+type RecipeItem struct {
+	ID    string `json:"id" db:"item_id"`
+	Count int    `json:"count" db:"count"`
+	Via   string `json:"via,omitempty" db:"via"`
+}
+
+type RecipeQuality struct {
+	ID     string `json:"id" db:"quality_id"`
+	Level  int    `json:"level" db:"level"`
+	Amount int    `json:"amount" db:"amount"`
+	Via    string `json:"via,omitempty" db:"via"`
+}
+
+type RecipeSkill struct {
+	ID    string `json:"id" db:"skill_id"`
+	Level int    `json:"level" db:"level"`
+}
+
+type recipeKey struct {
+	Type string
+	ID   string
+}
+
+// groupedItem places an alternative in its group, growing the list of groups
+// as needed.
+func groupedItem(groups [][]*RecipeItem, group int, item *RecipeItem) [][]*RecipeItem {
+	for len(groups) <= group {
+		groups = append(groups, []*RecipeItem{})
+	}
+	groups[group] = append(groups[group], item)
+	return groups
+}
+
+func groupedQuality(groups [][]*RecipeQuality, group int, q *RecipeQuality) [][]*RecipeQuality {
+	for len(groups) <= group {
+		groups = append(groups, []*RecipeQuality{})
+	}
+	groups[group] = append(groups[group], q)
+	return groups
+}
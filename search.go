@@ -0,0 +1,45 @@
+package cddadb
+
+import (
+	"regexp"
+	"strings"
+)
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+)
+
+// SearchResults are the best matches for a search along with how many items
+// of each type matched in all.
+type SearchResults struct {
+	Query   string       `json:"query"`
+	Total   int          `json:"total"`
+	Types   []*TypeCount `json:"types"`
+	Results []*SearchHit `json:"results"`
+}
+
+// SearchHit is one matching item. Snippet is the name and description with
+// the matched words wrapped in <mark> tags.
+type SearchHit struct {
+	ID      string  `json:"id" db:"id"`
+	Type    string  `json:"type" db:"type"`
+	Name    string  `json:"name" db:"name"`
+	Rank    float64 `json:"rank" db:"rank"`
+	Snippet string  `json:"snippet" db:"snippet"`
+}
+
+var searchWord = regexp.MustCompile(`[\pL\pN]+`)
+
+// searchQuery turns what someone typed into a tsquery that wants every word,
+// each as a prefix so fragments like "rif" still find rifles.
+func searchQuery(q string) (string, error) {
+	words := searchWord.FindAllString(strings.ToLower(q), -1)
+	if len(words) == 0 {
+		return "", &BadRequestError{Message: "search needs at least one word"}
+	}
+	for i, w := range words {
+		words[i] = w + ":*"
+	}
+	return strings.Join(words, " & "), nil
+}
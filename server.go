@@ -2,7 +2,10 @@ package cddadb
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/gorilla/mux"
 	log "github.com/sirupsen/logrus"
@@ -12,10 +15,40 @@ func CreateRouter(server *HTTPServer) (*mux.Router, error) {
 	r := mux.NewRouter()
 	m := map[string]map[string]HttpApiFunc{
 		"GET": {
-			"/api/items": server.GetItems,
+			"/api/datasets":                 server.GetDatasets,
+			"/api/diff":                     server.GetDiff,
+			"/api/items":                    server.GetItems,
+			"/api/items/{id}":               server.GetItem,
+			"/api/items/{id}/recipes":       server.GetRecipes,
+			"/api/items/{id}/used-in":       server.GetUsedIn,
+			"/api/items/{id}/disassembly":   server.GetDisassembly,
+			"/api/items/{id}/item-groups":   server.GetItemSpawns,
+			"/api/item-groups/{id}/expand":  server.ExpandItemGroup,
+			"/api/monsters":                 server.GetMonsters,
+			"/api/monsters/{id}":            server.GetMonster,
+			"/api/monstergroups/{id}":       server.GetMonsterGroup,
+			"/api/vehicles/{id}":            server.GetVehicle,
+			"/api/vehicle-parts/{id}":       server.GetVehiclePart,
+			"/api/terrain/{id}":             server.GetTerrain,
+			"/api/furniture/{id}":           server.GetFurniture,
+			"/api/mutations/{id}/tree":      server.GetMutationTree,
+			"/api/mutation-categories":      server.GetMutationCategories,
+			"/api/bionics":                  server.GetBionics,
+			"/api/bionics/{id}":             server.GetBionic,
+			"/api/refs/{id}":                server.GetReferences,
+			"/api/items/{id}/protection":    server.GetItemProtection,
+			"/api/armor":                    server.RankArmor,
+			"/api/items/{id}/compatibility": server.GetGunCompatibility,
+			"/api/food":                     server.GetFood,
+			"/api/languages":                server.GetLanguages,
+			"/api/types":                    server.GetTypes,
+			"/api/objects/{type}":           server.GetObjects,
+			"/api/search":                   server.Search,
 		},
-		"POST": {},
-		"PUT":  {},
+		"POST": {
+			"/api/items/{id}/craft": server.Craft,
+		},
+		"PUT": {},
 		"OPTIONS": {
 			"": options,
 		},
@@ -52,6 +85,7 @@ func writeCorsHeaders(w http.ResponseWriter, r *http.Request) {
 	w.Header().Add("Access-Control-Allow-Origin", "*")
 	w.Header().Add("Access-Control-Allow-Headers", "Origin, X-Requested-With, Content-Type, Accept")
 	w.Header().Add("Access-Control-Allow-Methods", "GET, POST, DELETE, PUT, OPTIONS")
+	w.Header().Add("Access-Control-Expose-Headers", "Link, X-Next-Cursor")
 }
 
 type HttpApiFunc func(w http.ResponseWriter, r *http.Request, vars map[string]string) error
@@ -85,6 +119,13 @@ func writeJSONDirect(w http.ResponseWriter, code int, thing []byte) {
 func httpError(w http.ResponseWriter, err error) {
 	statusCode := http.StatusInternalServerError
 
+	switch err.(type) {
+	case *NotFoundError:
+		statusCode = http.StatusNotFound
+	case *BadRequestError:
+		statusCode = http.StatusBadRequest
+	}
+
 	if err != nil {
 		log.WithField("err", err).Error("http error")
 		http.Error(w, err.Error(), statusCode)
@@ -96,14 +137,540 @@ func options(w http.ResponseWriter, r *http.Request, vars map[string]string) err
 	return nil
 }
 
+// version is the dataset a request asks for with ?version=, empty meaning
+// the most recently loaded one.
+func version(r *http.Request) string {
+	return r.URL.Query().Get("version")
+}
+
+// languages is the languages a request asks for, through ?lang= or its
+// Accept-Language header.
+func languages(r *http.Request) []string {
+	return AcceptedLanguages(r.URL.Query().Get("lang"), r.Header.Get("Accept-Language"))
+}
+
+// localized marks a response that depends on the language asked for, and
+// which language it is in when it was translated.
+func localized(w http.ResponseWriter, t *Localized) {
+	lang := ""
+	if t != nil {
+		lang = t.Lang
+	}
+	translatedInto(w, lang)
+}
+
+// translatedInto marks a response whose names are given in lang, empty
+// meaning they are in English.
+func translatedInto(w http.ResponseWriter, lang string) {
+	w.Header().Add("Vary", "Accept-Language")
+	if lang != "" {
+		w.Header().Set("Content-Language", strings.Replace(lang, "_", "-", -1))
+	}
+}
+
+func (s *HTTPServer) GetDatasets(w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	datasets, err := s.DB.GetDatasets()
+
+	if err != nil {
+		return err
+	}
+
+	writeJSON(w, http.StatusOK, datasets)
+
+	return nil
+}
+
 func (s *HTTPServer) GetItems(w http.ResponseWriter, r *http.Request, vars map[string]string) error {
-	items, err := s.DB.GetItems()
+	q, err := ParseItemQuery(r.URL.Query())
+	if err != nil {
+		return err
+	}
+	q.Lang, err = s.DB.Language(q.Version, languages(r))
+	if err != nil {
+		return err
+	}
+
+	items, next, err := s.DB.GetItems(q)
 
 	if err != nil {
 		return err
 	}
 
+	if next != nil {
+		link := *r.URL
+		query := link.Query()
+		query.Set("cursor", next.Encode())
+		link.RawQuery = query.Encode()
+		w.Header().Set("Link", fmt.Sprintf("<%s>; rel=\"next\"", link.RequestURI()))
+		w.Header().Set("X-Next-Cursor", next.Encode())
+	}
+
+	translatedInto(w, q.Lang)
+
 	writeJSON(w, http.StatusOK, items)
 
 	return nil
 }
+
+func (s *HTTPServer) GetItem(w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	item, err := s.DB.GetItem(version(r), vars["id"], languages(r))
+
+	if err != nil {
+		return err
+	}
+
+	localized(w, item.Translation)
+
+	writeJSON(w, http.StatusOK, item)
+
+	return nil
+}
+
+func (s *HTTPServer) GetTypes(w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	types, err := s.DB.GetTypes(version(r))
+
+	if err != nil {
+		return err
+	}
+
+	writeJSON(w, http.StatusOK, types)
+
+	return nil
+}
+
+func (s *HTTPServer) GetObjects(w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	objects, err := s.DB.GetObjects(version(r), vars["type"])
+
+	if err != nil {
+		return err
+	}
+
+	writeJSON(w, http.StatusOK, objects)
+
+	return nil
+}
+
+func (s *HTTPServer) GetDiff(w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	q := r.URL.Query()
+	if q.Get("from") == "" || q.Get("to") == "" {
+		return &BadRequestError{Message: "diff needs both from and to versions"}
+	}
+
+	diff, err := s.DB.GetDiff(q.Get("from"), q.Get("to"))
+
+	if err != nil {
+		return err
+	}
+
+	writeJSON(w, http.StatusOK, diff)
+
+	return nil
+}
+
+func (s *HTTPServer) Search(w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	q := r.URL.Query()
+
+	limit := defaultSearchLimit
+	if l := q.Get("limit"); l != "" {
+		n, err := strconv.Atoi(l)
+		if err != nil || n < 1 || n > maxSearchLimit {
+			return &BadRequestError{Message: fmt.Sprintf("limit must be between 1 and %d", maxSearchLimit)}
+		}
+		limit = n
+	}
+
+	lang, err := s.DB.Language(version(r), languages(r))
+	if err != nil {
+		return err
+	}
+
+	results, err := s.DB.SearchItems(version(r), q.Get("q"), list(q["type"]), limit, lang)
+
+	if err != nil {
+		return err
+	}
+
+	translatedInto(w, lang)
+
+	writeJSON(w, http.StatusOK, results)
+
+	return nil
+}
+
+func (s *HTTPServer) GetRecipes(w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	lang, err := s.DB.Language(version(r), languages(r))
+	if err != nil {
+		return err
+	}
+
+	recipes, err := s.DB.GetRecipes(version(r), vars["id"], lang)
+
+	if err != nil {
+		return err
+	}
+
+	translatedInto(w, lang)
+
+	writeJSON(w, http.StatusOK, recipes)
+
+	return nil
+}
+
+func (s *HTTPServer) GetUsedIn(w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	lang, err := s.DB.Language(version(r), languages(r))
+	if err != nil {
+		return err
+	}
+
+	recipes, err := s.DB.GetUsedIn(version(r), vars["id"], lang)
+
+	if err != nil {
+		return err
+	}
+
+	translatedInto(w, lang)
+
+	writeJSON(w, http.StatusOK, recipes)
+
+	return nil
+}
+
+func (s *HTTPServer) GetDisassembly(w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	lang, err := s.DB.Language(version(r), languages(r))
+	if err != nil {
+		return err
+	}
+
+	recipes, err := s.DB.GetDisassembly(version(r), vars["id"], lang)
+
+	if err != nil {
+		return err
+	}
+
+	translatedInto(w, lang)
+
+	writeJSON(w, http.StatusOK, recipes)
+
+	return nil
+}
+
+func (s *HTTPServer) Craft(w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	req := &CraftRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		return &BadRequestError{Message: "malformed inventory: " + err.Error()}
+	}
+
+	plan, err := s.DB.GetCraftPlan(version(r), vars["id"], req)
+
+	if err != nil {
+		return err
+	}
+
+	writeJSON(w, http.StatusOK, plan)
+
+	return nil
+}
+
+func (s *HTTPServer) ExpandItemGroup(w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	expansion, err := s.DB.GetItemGroupExpansion(version(r), vars["id"])
+
+	if err != nil {
+		return err
+	}
+
+	writeJSON(w, http.StatusOK, expansion)
+
+	return nil
+}
+
+func (s *HTTPServer) GetItemSpawns(w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	spawns, err := s.DB.GetItemSpawns(version(r), vars["id"])
+
+	if err != nil {
+		return err
+	}
+
+	writeJSON(w, http.StatusOK, spawns)
+
+	return nil
+}
+
+func (s *HTTPServer) GetMonsters(w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	lang, err := s.DB.Language(version(r), languages(r))
+	if err != nil {
+		return err
+	}
+
+	monsters, err := s.DB.GetMonsters(version(r), lang)
+
+	if err != nil {
+		return err
+	}
+
+	translatedInto(w, lang)
+
+	writeJSON(w, http.StatusOK, monsters)
+
+	return nil
+}
+
+func (s *HTTPServer) GetMonster(w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	monster, err := s.DB.GetMonster(version(r), vars["id"], languages(r))
+
+	if err != nil {
+		return err
+	}
+
+	localized(w, monster.Translation)
+
+	writeJSON(w, http.StatusOK, monster)
+
+	return nil
+}
+
+func (s *HTTPServer) GetMonsterGroup(w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	group, err := s.DB.GetMonsterGroup(version(r), vars["id"])
+
+	if err != nil {
+		return err
+	}
+
+	writeJSON(w, http.StatusOK, group)
+
+	return nil
+}
+
+func (s *HTTPServer) GetVehicle(w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	vehicle, err := s.DB.GetVehicle(version(r), vars["id"])
+
+	if err != nil {
+		return err
+	}
+
+	writeJSON(w, http.StatusOK, vehicle)
+
+	return nil
+}
+
+func (s *HTTPServer) GetVehiclePart(w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	part, err := s.DB.GetVehiclePart(version(r), vars["id"])
+
+	if err != nil {
+		return err
+	}
+
+	writeJSON(w, http.StatusOK, part)
+
+	return nil
+}
+
+func (s *HTTPServer) GetTerrain(w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	lang, err := s.DB.Language(version(r), languages(r))
+	if err != nil {
+		return err
+	}
+
+	terrain, err := s.DB.GetTerrain(version(r), vars["id"], lang)
+
+	if err != nil {
+		return err
+	}
+
+	translatedInto(w, lang)
+
+	writeJSON(w, http.StatusOK, terrain)
+
+	return nil
+}
+
+func (s *HTTPServer) GetFurniture(w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	lang, err := s.DB.Language(version(r), languages(r))
+	if err != nil {
+		return err
+	}
+
+	furniture, err := s.DB.GetFurniture(version(r), vars["id"], lang)
+
+	if err != nil {
+		return err
+	}
+
+	translatedInto(w, lang)
+
+	writeJSON(w, http.StatusOK, furniture)
+
+	return nil
+}
+
+func (s *HTTPServer) GetMutationTree(w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	lang, err := s.DB.Language(version(r), languages(r))
+	if err != nil {
+		return err
+	}
+
+	tree, err := s.DB.GetMutationTree(version(r), vars["id"], lang)
+
+	if err != nil {
+		return err
+	}
+
+	translatedInto(w, lang)
+
+	writeJSON(w, http.StatusOK, tree)
+
+	return nil
+}
+
+func (s *HTTPServer) GetMutationCategories(w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	lang, err := s.DB.Language(version(r), languages(r))
+	if err != nil {
+		return err
+	}
+
+	categories, err := s.DB.GetMutationCategories(version(r), lang)
+
+	if err != nil {
+		return err
+	}
+
+	translatedInto(w, lang)
+
+	writeJSON(w, http.StatusOK, categories)
+
+	return nil
+}
+
+func (s *HTTPServer) GetBionics(w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	lang, err := s.DB.Language(version(r), languages(r))
+	if err != nil {
+		return err
+	}
+
+	bionics, err := s.DB.GetBionics(version(r), lang)
+
+	if err != nil {
+		return err
+	}
+
+	translatedInto(w, lang)
+
+	writeJSON(w, http.StatusOK, bionics)
+
+	return nil
+}
+
+func (s *HTTPServer) GetBionic(w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	lang, err := s.DB.Language(version(r), languages(r))
+	if err != nil {
+		return err
+	}
+
+	bionic, err := s.DB.GetBionic(version(r), vars["id"], lang)
+
+	if err != nil {
+		return err
+	}
+
+	translatedInto(w, lang)
+
+	writeJSON(w, http.StatusOK, bionic)
+
+	return nil
+}
+
+func (s *HTTPServer) GetReferences(w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	refs, err := s.DB.GetReferences(version(r), vars["id"])
+
+	if err != nil {
+		return err
+	}
+
+	writeJSON(w, http.StatusOK, refs)
+
+	return nil
+}
+
+func (s *HTTPServer) GetItemProtection(w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	protection, err := s.DB.GetItemProtection(version(r), vars["id"])
+
+	if err != nil {
+		return err
+	}
+
+	writeJSON(w, http.StatusOK, protection)
+
+	return nil
+}
+
+func (s *HTTPServer) RankArmor(w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	q := r.URL.Query()
+
+	bodyPart := q.Get("body_part")
+	if bodyPart == "" {
+		return &BadRequestError{Message: "body_part is required"}
+	}
+	damage := q.Get("damage")
+	if damage == "" {
+		damage = "bash"
+	}
+
+	limit := defaultArmorLimit
+	if l := q.Get("limit"); l != "" {
+		n, err := strconv.Atoi(l)
+		if err != nil || n < 1 || n > maxArmorLimit {
+			return &BadRequestError{Message: fmt.Sprintf("limit must be between 1 and %d", maxArmorLimit)}
+		}
+		limit = n
+	}
+
+	ranks, err := s.DB.GetArmorRanking(version(r), bodyPart, damage, limit)
+
+	if err != nil {
+		return err
+	}
+
+	writeJSON(w, http.StatusOK, ranks)
+
+	return nil
+}
+
+func (s *HTTPServer) GetGunCompatibility(w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	compatibility, err := s.DB.GetGunCompatibility(version(r), vars["id"])
+
+	if err != nil {
+		return err
+	}
+
+	writeJSON(w, http.StatusOK, compatibility)
+
+	return nil
+}
+
+func (s *HTTPServer) GetFood(w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	q, err := ParseFoodQuery(r.URL.Query())
+	if err != nil {
+		return err
+	}
+
+	food, err := s.DB.GetFood(q)
+
+	if err != nil {
+		return err
+	}
+
+	writeJSON(w, http.StatusOK, food)
+
+	return nil
+}
+
+func (s *HTTPServer) GetLanguages(w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	langs, err := s.DB.GetLanguages(version(r))
+
+	if err != nil {
+		return err
+	}
+
+	writeJSON(w, http.StatusOK, langs)
+
+	return nil
+}
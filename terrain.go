@@ -0,0 +1,214 @@
+package cddadb
+
+import (
+	"encoding/json"
+
+	"github.com/ralreegorganon/cddadb/units"
+)
+
+// Terrain is a resolved terrain with what bashing and deconstructing it
+// yields. Symbol and Color hold one entry, or four when they change with the
+// seasons.
+type Terrain struct {
+	ID          string       `json:"id"`
+	Name        string       `json:"name"`
+	Description string       `json:"description"`
+	Symbol      []string     `json:"symbol"`
+	Color       []string     `json:"color"`
+	MoveCost    int          `json:"move_cost"`
+	Flags       []string     `json:"flags"`
+	ConnectsTo  string       `json:"connects_to,omitempty"`
+	Roof        string       `json:"roof,omitempty"`
+	Open        string       `json:"open,omitempty"`
+	Close       string       `json:"close,omitempty"`
+	Bash        *Bash        `json:"bash"`
+	Deconstruct *Deconstruct `json:"deconstruct"`
+}
+
+// Furniture is a resolved piece of furniture with what bashing and
+// deconstructing it yields. MoveCostMod is added to the move cost of the
+// terrain under it, and MaxVolume is in millilitres.
+type Furniture struct {
+	ID                 string       `json:"id"`
+	Name               string       `json:"name"`
+	Description        string       `json:"description"`
+	Symbol             []string     `json:"symbol"`
+	Color              []string     `json:"color"`
+	MoveCostMod        int          `json:"move_cost_mod"`
+	RequiredStr        int          `json:"required_str"`
+	MaxVolume          units.Volume `json:"max_volume"`
+	Flags              []string     `json:"flags"`
+	CraftingPseudoItem string       `json:"crafting_pseudo_item,omitempty"`
+	DeployedItem       string       `json:"deployed_item,omitempty"`
+	Open               string       `json:"open,omitempty"`
+	Close              string       `json:"close,omitempty"`
+	Bash               *Bash        `json:"bash"`
+	Deconstruct        *Deconstruct `json:"deconstruct"`
+}
+
+// Bash is what it takes to smash terrain or furniture and what is left
+// afterwards. Result is the terrain or furniture it turns into.
+type Bash struct {
+	StrMin int                 `json:"str_min"`
+	StrMax int                 `json:"str_max"`
+	Sound  string              `json:"sound,omitempty"`
+	Result string              `json:"result,omitempty"`
+	Items  *ItemGroupExpansion `json:"items,omitempty"`
+
+	items json.RawMessage
+}
+
+// Deconstruct is what taking terrain or furniture apart leaves and turns it
+// into.
+type Deconstruct struct {
+	Result string              `json:"result,omitempty"`
+	Items  *ItemGroupExpansion `json:"items,omitempty"`
+
+	items json.RawMessage
+}
+
+type bashJSON struct {
+	StrMin  int             `json:"str_min"`
+	StrMax  int             `json:"str_max"`
+	Sound   string          `json:"sound"`
+	TerSet  string          `json:"ter_set"`
+	FurnSet string          `json:"furn_set"`
+	Items   json.RawMessage `json:"items"`
+}
+
+type deconstructJSON struct {
+	TerSet  string          `json:"ter_set"`
+	FurnSet string          `json:"furn_set"`
+	Items   json.RawMessage `json:"items"`
+}
+
+type mapObjectJSON struct {
+	ID                 string           `json:"id"`
+	Name               Translation      `json:"name"`
+	Description        string           `json:"description"`
+	Symbol             Tags             `json:"symbol"`
+	Color              Tags             `json:"color"`
+	BgColor            Tags             `json:"bgcolor"`
+	MoveCost           int              `json:"move_cost"`
+	MoveCostMod        int              `json:"move_cost_mod"`
+	RequiredStr        int              `json:"required_str"`
+	MaxVolume          *units.Volume    `json:"max_volume"`
+	Flags              Tags             `json:"flags"`
+	ConnectsTo         string           `json:"connects_to"`
+	Roof               string           `json:"roof"`
+	Open               string           `json:"open"`
+	Close              string           `json:"close"`
+	CraftingPseudoItem string           `json:"crafting_pseudo_item"`
+	DeployedItem       string           `json:"deployed_item"`
+	Bash               *bashJSON        `json:"bash"`
+	Deconstruct        *deconstructJSON `json:"deconstruct"`
+}
+
+// defaultMaxVolume is how much fits on a tile of furniture that doesn't say,
+// a thousand litres.
+const defaultMaxVolume units.Volume = 1000 * 1000
+
+// ParseTerrain reads a resolved terrain definition.
+func ParseTerrain(resolved []byte) (*Terrain, error) {
+	var j mapObjectJSON
+	if err := json.Unmarshal(resolved, &j); err != nil {
+		return nil, err
+	}
+	return &Terrain{
+		ID:          j.ID,
+		Name:        j.Name.Str,
+		Description: j.Description,
+		Symbol:      nonNil(j.Symbol),
+		Color:       j.color(),
+		MoveCost:    j.MoveCost,
+		Flags:       nonNil(j.Flags),
+		ConnectsTo:  j.ConnectsTo,
+		Roof:        j.Roof,
+		Open:        j.Open,
+		Close:       j.Close,
+		Bash:        j.bash(),
+		Deconstruct: j.deconstruct(),
+	}, nil
+}
+
+// ParseFurniture reads a resolved furniture definition.
+func ParseFurniture(resolved []byte) (*Furniture, error) {
+	var j mapObjectJSON
+	if err := json.Unmarshal(resolved, &j); err != nil {
+		return nil, err
+	}
+	f := &Furniture{
+		ID:                 j.ID,
+		Name:               j.Name.Str,
+		Description:        j.Description,
+		Symbol:             nonNil(j.Symbol),
+		Color:              j.color(),
+		MoveCostMod:        j.MoveCostMod,
+		RequiredStr:        j.RequiredStr,
+		MaxVolume:          defaultMaxVolume,
+		Flags:              nonNil(j.Flags),
+		CraftingPseudoItem: j.CraftingPseudoItem,
+		DeployedItem:       j.DeployedItem,
+		Open:               j.Open,
+		Close:              j.Close,
+		Bash:               j.bash(),
+		Deconstruct:        j.deconstruct(),
+	}
+	if j.MaxVolume != nil {
+		f.MaxVolume = *j.MaxVolume
+	}
+	return f, nil
+}
+
+// color is the foreground color, or the background color for objects that
+// only give one.
+func (j *mapObjectJSON) color() []string {
+	if len(j.Color) == 0 {
+		return nonNil(j.BgColor)
+	}
+	return j.Color
+}
+
+func (j *mapObjectJSON) bash() *Bash {
+	if j.Bash == nil {
+		return nil
+	}
+	b := &Bash{
+		StrMin: j.Bash.StrMin,
+		StrMax: j.Bash.StrMax,
+		Sound:  j.Bash.Sound,
+		Result: j.Bash.TerSet,
+		items:  j.Bash.Items,
+	}
+	if b.Result == "" {
+		b.Result = j.Bash.FurnSet
+	}
+	return b
+}
+
+func (j *mapObjectJSON) deconstruct() *Deconstruct {
+	if j.Deconstruct == nil {
+		return nil
+	}
+	d := &Deconstruct{Result: j.Deconstruct.TerSet, items: j.Deconstruct.Items}
+	if d.Result == "" {
+		d.Result = j.Deconstruct.FurnSet
+	}
+	return d
+}
+
+// hasYields reports whether bashing or deconstructing gives any items.
+func hasYields(b *Bash, d *Deconstruct) bool {
+	return (b != nil && len(b.items) > 0) || (d != nil && len(d.items) > 0)
+}
+
+// expandYields fills in the items bashing and deconstructing give, which the
+// game reads as collections when written inline.
+func expandYields(x *ItemGroupExpander, id string, b *Bash, d *Deconstruct) {
+	if b != nil {
+		b.Items = x.ExpandInline(b.items, id+"_bash", "collection")
+	}
+	if d != nil {
+		d.Items = x.ExpandInline(d.items, id+"_deconstruct", "collection")
+	}
+}
@@ -0,0 +1,107 @@
+package cddadb
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// Localized is the name and description of an object in another language.
+// NameForms are the plural forms of the name, numbered by the language's
+// PluralForms rule, and Name is the one for a single object. Either of Name
+// and Description is empty when only the other is translated.
+type Localized struct {
+	Lang        string         `json:"lang" db:"lang"`
+	Name        string         `json:"name" db:"name"`
+	NameForms   pq.StringArray `json:"name_forms" db:"name_forms"`
+	PluralForms string         `json:"plural_forms" db:"plural_forms"`
+	Description string         `json:"description" db:"description"`
+}
+
+// Language is a language translations have been loaded for.
+type Language struct {
+	Lang        string `json:"lang" db:"lang"`
+	NPlurals    int    `json:"nplurals" db:"nplurals"`
+	PluralForms string `json:"plural_forms" db:"plural_forms"`
+}
+
+// AcceptedLanguages lists the languages a request asks for, most preferred
+// first: the lang parameter if given, then those of an Accept-Language
+// header in order of quality. Tags are written the way the game names its
+// catalogs, pt_BR rather than pt-BR.
+func AcceptedLanguages(lang, acceptLanguage string) []string {
+	if lang != "" {
+		return []string{normalizeLanguage(lang)}
+	}
+
+	type weighted struct {
+		lang string
+		q    float64
+	}
+	ws := []weighted{}
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		fields := strings.Split(part, ";")
+		tag := strings.TrimSpace(fields[0])
+		if tag == "" || tag == "*" {
+			continue
+		}
+		q := 1.0
+		for _, f := range fields[1:] {
+			f = strings.TrimSpace(f)
+			if strings.HasPrefix(f, "q=") {
+				if v, err := strconv.ParseFloat(f[2:], 64); err == nil {
+					q = v
+				}
+			}
+		}
+		if q > 0 {
+			ws = append(ws, weighted{normalizeLanguage(tag), q})
+		}
+	}
+	sort.SliceStable(ws, func(i, j int) bool { return ws[i].q > ws[j].q })
+
+	langs := []string{}
+	for _, w := range ws {
+		langs = append(langs, w.lang)
+	}
+	return langs
+}
+
+func normalizeLanguage(tag string) string {
+	parts := strings.Split(strings.Replace(strings.TrimSpace(tag), "-", "_", -1), "_")
+	parts[0] = strings.ToLower(parts[0])
+	for i := 1; i < len(parts); i++ {
+		if len(parts[i]) == 2 {
+			parts[i] = strings.ToUpper(parts[i])
+		}
+	}
+	return strings.Join(parts, "_")
+}
+
+// matchLanguage picks the first of the accepted languages that is available,
+// taking a language for a regional variant of it and the other way around
+// when there is no exact match. English is the untranslated data, so asking
+// for it picks nothing.
+func matchLanguage(accepted, available []string) string {
+	primary := func(lang string) string {
+		return strings.SplitN(lang, "_", 2)[0]
+	}
+	for _, a := range accepted {
+		if primary(a) == "en" {
+			return ""
+		}
+		for _, l := range available {
+			if strings.EqualFold(a, l) {
+				return l
+			}
+		}
+		for _, l := range available {
+			if primary(a) == primary(l) {
+				return l
+			}
+		}
+	}
+	return ""
+}
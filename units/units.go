@@ -0,0 +1,261 @@
+// Package units parses the measured quantities in the game data into
+// canonical integers.
+//
+// Newer data writes quantities as strings with units, "250 g", "1 L",
+// "10 kJ" or "1 h 30 m", with or without the spaces. Older data uses bare
+// numbers in a fixed unit, which for volume is the legacy 250 ml step rather
+// than a millilitre.
+package units
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Mass is a weight in grams.
+type Mass int64
+
+// Volume is a volume in millilitres.
+type Volume int64
+
+// Energy is an amount of energy in millijoules.
+type Energy int64
+
+// Duration is a length of time in turns, one turn being one second as it is
+// from 0.D on.
+type Duration int64
+
+// LegacyVolumeFactor is the size in millilitres of one unit of a bare volume.
+const LegacyVolumeFactor = 250
+
+const (
+	Millijoules Energy = 1
+	Joules      Energy = 1000 * Millijoules
+	Kilojoules  Energy = 1000 * Joules
+)
+
+// A turn has been one second since the 0.D experimental builds this package
+// is written against. 0.C and earlier used six second turns, so durations
+// read from data that old come out six times too short.
+const (
+	Turns   Duration = 1
+	Seconds Duration = 1
+	Minutes Duration = 60 * Seconds
+	Hours   Duration = 60 * Minutes
+	Days    Duration = 24 * Hours
+)
+
+// Kind names a measured quantity.
+type Kind string
+
+const (
+	KindMass     Kind = "mass"
+	KindVolume   Kind = "volume"
+	KindEnergy   Kind = "energy"
+	KindDuration Kind = "duration"
+)
+
+var tables = map[Kind]map[string]float64{
+	KindMass: {
+		"mg": 0.001,
+		"g":  1,
+		"kg": 1000,
+	},
+	KindVolume: {
+		"ml": 1,
+		"L":  1000,
+	},
+	KindEnergy: {
+		"mJ": 1,
+		"J":  1000,
+		"kJ": 1000000,
+	},
+	KindDuration: {
+		"turns":   float64(Turns),
+		"turn":    float64(Turns),
+		"t":       float64(Turns),
+		"seconds": float64(Seconds),
+		"second":  float64(Seconds),
+		"s":       float64(Seconds),
+		"minutes": float64(Minutes),
+		"minute":  float64(Minutes),
+		"m":       float64(Minutes),
+		"hours":   float64(Hours),
+		"hour":    float64(Hours),
+		"h":       float64(Hours),
+		"days":    float64(Days),
+		"day":     float64(Days),
+		"d":       float64(Days),
+	},
+}
+
+// canonical is the unit Format writes each kind in.
+var canonical = map[Kind]string{
+	KindMass:     "g",
+	KindVolume:   "ml",
+	KindEnergy:   "mJ",
+	KindDuration: "s",
+}
+
+// Parse reads a string of one or more "<number> <unit>" terms, all of the
+// same kind, and returns their sum in the canonical unit. The space between
+// a number and its unit is optional, so "250ml" and "1h30m" read too.
+func Parse(s string) (Kind, float64, error) {
+	rest := strings.TrimSpace(s)
+	if rest == "" {
+		return "", 0, fmt.Errorf("malformed quantity %q", s)
+	}
+
+	var kind Kind
+	total := 0.0
+	for rest != "" {
+		n := strings.IndexFunc(rest, func(r rune) bool {
+			return !unicode.IsDigit(r) && r != '.' && r != '-' && r != '+'
+		})
+		if n <= 0 {
+			return "", 0, fmt.Errorf("malformed quantity %q", s)
+		}
+		v, err := strconv.ParseFloat(rest[:n], 64)
+		if err != nil {
+			return "", 0, fmt.Errorf("malformed quantity %q", s)
+		}
+		rest = strings.TrimLeftFunc(rest[n:], unicode.IsSpace)
+
+		u := strings.IndexFunc(rest, func(r rune) bool { return !unicode.IsLetter(r) })
+		if u < 0 {
+			u = len(rest)
+		}
+		if u == 0 {
+			return "", 0, fmt.Errorf("malformed quantity %q", s)
+		}
+		k, factor, ok := lookup(rest[:u])
+		if !ok {
+			return "", 0, fmt.Errorf("unknown unit %q in %q", rest[:u], s)
+		}
+		rest = strings.TrimLeftFunc(rest[u:], unicode.IsSpace)
+
+		if kind != "" && k != kind {
+			return "", 0, fmt.Errorf("mixed units in %q", s)
+		}
+		kind = k
+		total += v * factor
+	}
+	return kind, total, nil
+}
+
+func lookup(unit string) (Kind, float64, bool) {
+	for k, t := range tables {
+		if f, ok := t[unit]; ok {
+			return k, f, true
+		}
+	}
+	return "", 0, false
+}
+
+// Format writes a canonical value of the given kind back out as a string.
+func Format(kind Kind, v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64) + " " + canonical[kind]
+}
+
+// parse reads a quantity of the expected kind from decoded JSON, scaling a
+// bare number by bare.
+func parse(kind Kind, v interface{}, bare float64) (int64, error) {
+	switch t := v.(type) {
+	case float64:
+		return round(t * bare), nil
+	case json.Number:
+		f, err := t.Float64()
+		if err != nil {
+			return 0, err
+		}
+		return round(f * bare), nil
+	case string:
+		k, f, err := Parse(t)
+		if err != nil {
+			return 0, err
+		}
+		if k != kind {
+			return 0, fmt.Errorf("expected a %s, got %q", kind, t)
+		}
+		return round(f), nil
+	}
+	return 0, fmt.Errorf("expected a %s, got %v", kind, v)
+}
+
+func round(f float64) int64 {
+	return int64(math.Round(f))
+}
+
+// ParseMass reads a weight, a bare number being grams.
+func ParseMass(v interface{}) (Mass, error) {
+	n, err := parse(KindMass, v, 1)
+	return Mass(n), err
+}
+
+// ParseVolume reads a volume, a bare number being legacy 250 ml units.
+func ParseVolume(v interface{}) (Volume, error) {
+	n, err := parse(KindVolume, v, LegacyVolumeFactor)
+	return Volume(n), err
+}
+
+// ParseEnergy reads an amount of energy, a bare number being millijoules.
+func ParseEnergy(v interface{}) (Energy, error) {
+	n, err := parse(KindEnergy, v, 1)
+	return Energy(n), err
+}
+
+// ParseDuration reads a length of time. Bare numbers mean different things
+// for different members so the caller says how long one is.
+func ParseDuration(v interface{}, bare Duration) (Duration, error) {
+	n, err := parse(KindDuration, v, float64(bare))
+	return Duration(n), err
+}
+
+func decode(b []byte) (interface{}, error) {
+	var v interface{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func (m *Mass) UnmarshalJSON(b []byte) error {
+	v, err := decode(b)
+	if err != nil {
+		return err
+	}
+	*m, err = ParseMass(v)
+	return err
+}
+
+func (m *Volume) UnmarshalJSON(b []byte) error {
+	v, err := decode(b)
+	if err != nil {
+		return err
+	}
+	*m, err = ParseVolume(v)
+	return err
+}
+
+func (m *Energy) UnmarshalJSON(b []byte) error {
+	v, err := decode(b)
+	if err != nil {
+		return err
+	}
+	*m, err = ParseEnergy(v)
+	return err
+}
+
+// UnmarshalJSON reads a duration with bare numbers in turns.
+func (m *Duration) UnmarshalJSON(b []byte) error {
+	v, err := decode(b)
+	if err != nil {
+		return err
+	}
+	*m, err = ParseDuration(v, Turns)
+	return err
+}
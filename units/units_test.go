@@ -0,0 +1,90 @@
+package units
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in   string
+		kind Kind
+		want float64
+	}{
+		{"250 g", KindMass, 250},
+		{"250g", KindMass, 250},
+		{"1 kg 500 g", KindMass, 1500},
+		{"1kg500g", KindMass, 1500},
+		{"500 mg", KindMass, 0.5},
+		{"250 ml", KindVolume, 250},
+		{"250ml", KindVolume, 250},
+		{"1 L", KindVolume, 1000},
+		{"1L", KindVolume, 1000},
+		{"1.5 L", KindVolume, 1500},
+		{"10 kJ", KindEnergy, 10000000},
+		{"10kJ", KindEnergy, 10000000},
+		{"5 J", KindEnergy, 5000},
+		{"1 h 30 m", KindDuration, 5400},
+		{"1h30m", KindDuration, 5400},
+		{"1h 30m", KindDuration, 5400},
+		{"2 days", KindDuration, 172800},
+		{"10 turns", KindDuration, 10},
+		{"30 s", KindDuration, 30},
+		{" 1 m ", KindDuration, 60},
+	}
+	for _, tt := range tests {
+		kind, v, err := Parse(tt.in)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.in, err)
+			continue
+		}
+		if kind != tt.kind || v != tt.want {
+			t.Errorf("Parse(%q) = %s %v, want %s %v", tt.in, kind, v, tt.kind, tt.want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, in := range []string{
+		"",
+		"250",
+		"g",
+		"250 g 1",
+		"1 kg 1 L",
+		"1kg1L",
+		"1 h 10 g",
+		"10 parsecs",
+		"1..5 g",
+	} {
+		if kind, v, err := Parse(in); err == nil {
+			t.Errorf("Parse(%q) = %s %v, want an error", in, kind, v)
+		}
+	}
+}
+
+func TestParseBare(t *testing.T) {
+	tests := []struct {
+		name  string
+		parse func(interface{}) (int64, error)
+		in    interface{}
+		want  int64
+	}{
+		{"mass", func(v interface{}) (int64, error) { m, err := ParseMass(v); return int64(m), err }, 250.0, 250},
+		{"volume", func(v interface{}) (int64, error) { m, err := ParseVolume(v); return int64(m), err }, 4.0, 1000},
+		{"energy", func(v interface{}) (int64, error) { m, err := ParseEnergy(v); return int64(m), err }, 10.0, 10},
+		{"duration in turns", func(v interface{}) (int64, error) { m, err := ParseDuration(v, Turns); return int64(m), err }, 30.0, 30},
+		{"duration in minutes", func(v interface{}) (int64, error) { m, err := ParseDuration(v, Minutes); return int64(m), err }, 30.0, 1800},
+		{"volume with units", func(v interface{}) (int64, error) { m, err := ParseVolume(v); return int64(m), err }, "4 L", 4000},
+	}
+	for _, tt := range tests {
+		got, err := tt.parse(tt.in)
+		if err != nil {
+			t.Errorf("%s %v: %v", tt.name, tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s %v = %d, want %d", tt.name, tt.in, got, tt.want)
+		}
+	}
+
+	if _, err := ParseMass("1 L"); err == nil {
+		t.Error("ParseMass(\"1 L\") read a volume as a mass")
+	}
+}
@@ -0,0 +1,267 @@
+package cddadb
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/ralreegorganon/cddadb/units"
+)
+
+// VehiclePart is a resolved vehicle part. Power is the engine power in
+// watts and EPower the electrical power made, or used when negative.
+// Capacity is how much a tank or cargo space holds, in millilitres.
+type VehiclePart struct {
+	ID          string       `json:"id"`
+	Name        string       `json:"name"`
+	Description string       `json:"description"`
+	Location    string       `json:"location"`
+	Flags       []string     `json:"flags"`
+	Durability  int          `json:"durability"`
+	Power       int          `json:"power"`
+	EPower      int          `json:"epower"`
+	FuelType    string       `json:"fuel_type,omitempty"`
+	Capacity    units.Volume `json:"capacity"`
+	Item        *PartItem    `json:"item"`
+
+	Install    *Recipe             `json:"install,omitempty"`
+	BreaksInto *ItemGroupExpansion `json:"breaks_into,omitempty"`
+
+	breaksInto json.RawMessage
+}
+
+// PartItem is the item a vehicle part is installed from and comes back as
+// when removed. Weight is in grams.
+type PartItem struct {
+	ID     string `json:"id" db:"id"`
+	Name   string `json:"name" db:"name"`
+	Weight *int64 `json:"weight" db:"weight"`
+}
+
+type vehiclePartJSON struct {
+	ID          string          `json:"id"`
+	Name        Translation     `json:"name"`
+	Description string          `json:"description"`
+	Location    string          `json:"location"`
+	Flags       Tags            `json:"flags"`
+	Durability  int             `json:"durability"`
+	Power       int             `json:"power"`
+	EPower      int             `json:"epower"`
+	FuelType    string          `json:"fuel_type"`
+	Size        units.Volume    `json:"size"`
+	Item        string          `json:"item"`
+	BreaksInto  json.RawMessage `json:"breaks_into"`
+}
+
+// ParseVehiclePart reads a resolved vehicle_part definition.
+func ParseVehiclePart(resolved []byte) (*VehiclePart, error) {
+	var j vehiclePartJSON
+	if err := json.Unmarshal(resolved, &j); err != nil {
+		return nil, err
+	}
+	p := &VehiclePart{
+		ID:          j.ID,
+		Name:        j.Name.Str,
+		Description: j.Description,
+		Location:    j.Location,
+		Flags:       nonNil(j.Flags),
+		Durability:  j.Durability,
+		Power:       j.Power,
+		EPower:      j.EPower,
+		FuelType:    j.FuelType,
+		Capacity:    j.Size,
+		Item:        &PartItem{ID: j.Item},
+		breaksInto:  j.BreaksInto,
+	}
+	return p, nil
+}
+
+// HasFlag reports whether the part has a flag.
+func (p *VehiclePart) HasFlag(flag string) bool {
+	return hasTag(p.Flags, flag)
+}
+
+// expandBreaksInto fills in BreaksInto from the item group the part leaves
+// behind when destroyed, named or written inline.
+func (p *VehiclePart) expandBreaksInto(x *ItemGroupExpander) {
+	p.BreaksInto = x.ExpandInline(p.breaksInto, p.ID+"_breaks_into", "collection")
+}
+
+// Vehicle is a prebuilt vehicle with its parts laid out on a grid and the
+// totals worked out from them. Mass is the parts alone, in grams, and
+// FuelCapacity is in millilitres by fuel.
+type Vehicle struct {
+	ID           string                  `json:"id"`
+	Name         string                  `json:"name"`
+	Blueprint    []string                `json:"blueprint,omitempty"`
+	Bounds       VehicleBounds           `json:"bounds"`
+	Tiles        []*VehicleTile          `json:"tiles"`
+	Parts        map[string]*VehiclePart `json:"parts"`
+	Unknown      []string                `json:"unknown_parts"`
+	Mass         int64                   `json:"mass"`
+	FuelCapacity map[string]int64        `json:"fuel_capacity"`
+	EnginePower  int                     `json:"engine_power"`
+}
+
+// VehicleBounds is the smallest rectangle holding every part.
+type VehicleBounds struct {
+	MinX int `json:"min_x"`
+	MaxX int `json:"max_x"`
+	MinY int `json:"min_y"`
+	MaxY int `json:"max_y"`
+}
+
+// VehicleTile is the stack of parts mounted at one point of the grid, in the
+// order they are installed.
+type VehicleTile struct {
+	X     int                 `json:"x"`
+	Y     int                 `json:"y"`
+	Parts []*VehiclePlacement `json:"parts"`
+}
+
+// VehiclePlacement is one part mounted on a tile, with the fuel a tank
+// starts out holding.
+type VehiclePlacement struct {
+	Part string `json:"part"`
+	Fuel string `json:"fuel,omitempty"`
+}
+
+type vehicleJSON struct {
+	ID        string        `json:"id"`
+	Name      Translation   `json:"name"`
+	Blueprint Tags          `json:"blueprint"`
+	Parts     []vehicleTile `json:"parts"`
+}
+
+// vehicleTile is a parts entry of a vehicle, which places either a single
+// part or a list of parts, each an id or an object naming the part.
+type vehicleTile struct {
+	X     int               `json:"x"`
+	Y     int               `json:"y"`
+	Part  string            `json:"part"`
+	Fuel  string            `json:"fuel"`
+	Parts []json.RawMessage `json:"parts"`
+}
+
+// ParseVehicle reads a resolved vehicle definition into its grid, merging
+// entries that mount parts on the same point. Parts are left to be filled
+// in by Assemble.
+func ParseVehicle(resolved []byte) (*Vehicle, error) {
+	var j vehicleJSON
+	if err := json.Unmarshal(resolved, &j); err != nil {
+		return nil, err
+	}
+
+	v := &Vehicle{
+		ID:           j.ID,
+		Name:         j.Name.Str,
+		Blueprint:    j.Blueprint,
+		Tiles:        []*VehicleTile{},
+		Parts:        make(map[string]*VehiclePart),
+		Unknown:      []string{},
+		FuelCapacity: make(map[string]int64),
+	}
+
+	tiles := make(map[[2]int]*VehicleTile)
+	for _, t := range j.Parts {
+		placements := []*VehiclePlacement{}
+		if t.Part != "" {
+			placements = append(placements, &VehiclePlacement{Part: t.Part, Fuel: t.Fuel})
+		}
+		for _, raw := range t.Parts {
+			var id string
+			if err := json.Unmarshal(raw, &id); err == nil {
+				placements = append(placements, &VehiclePlacement{Part: id})
+				continue
+			}
+			p := &VehiclePlacement{}
+			if err := json.Unmarshal(raw, p); err != nil {
+				return nil, err
+			}
+			placements = append(placements, p)
+		}
+
+		k := [2]int{t.X, t.Y}
+		tile, ok := tiles[k]
+		if !ok {
+			tile = &VehicleTile{X: t.X, Y: t.Y, Parts: []*VehiclePlacement{}}
+			tiles[k] = tile
+			v.Tiles = append(v.Tiles, tile)
+		}
+		tile.Parts = append(tile.Parts, placements...)
+	}
+
+	sort.SliceStable(v.Tiles, func(i, k int) bool {
+		if v.Tiles[i].Y == v.Tiles[k].Y {
+			return v.Tiles[i].X < v.Tiles[k].X
+		}
+		return v.Tiles[i].Y < v.Tiles[k].Y
+	})
+	for i, t := range v.Tiles {
+		if i == 0 {
+			v.Bounds = VehicleBounds{MinX: t.X, MaxX: t.X, MinY: t.Y, MaxY: t.Y}
+			continue
+		}
+		v.Bounds.MinX = minInt(v.Bounds.MinX, t.X)
+		v.Bounds.MaxX = maxInt(v.Bounds.MaxX, t.X)
+		v.Bounds.MinY = minInt(v.Bounds.MinY, t.Y)
+		v.Bounds.MaxY = maxInt(v.Bounds.MaxY, t.Y)
+	}
+	return v, nil
+}
+
+// PartIDs lists the distinct parts the vehicle mounts.
+func (v *Vehicle) PartIDs() []string {
+	seen := make(map[string]bool)
+	ids := []string{}
+	for _, t := range v.Tiles {
+		for _, p := range t.Parts {
+			if !seen[p.Part] {
+				seen[p.Part] = true
+				ids = append(ids, p.Part)
+			}
+		}
+	}
+	return ids
+}
+
+// Assemble fills in the vehicle's parts and adds up its totals. Every
+// mounted part counts once towards each total; parts with no definition are
+// listed as unknown.
+func (v *Vehicle) Assemble(parts map[string]*VehiclePart) {
+	for _, id := range v.PartIDs() {
+		if p, ok := parts[id]; ok {
+			v.Parts[id] = p
+		} else {
+			v.Unknown = append(v.Unknown, id)
+		}
+	}
+
+	for _, t := range v.Tiles {
+		for _, placed := range t.Parts {
+			p, ok := v.Parts[placed.Part]
+			if !ok {
+				continue
+			}
+			if p.Item != nil && p.Item.Weight != nil {
+				v.Mass += *p.Item.Weight
+			}
+			if p.HasFlag("ENGINE") {
+				v.EnginePower += p.Power
+			}
+			if p.HasFlag("FUEL_TANK") {
+				fuel := placed.Fuel
+				if fuel == "" {
+					fuel = p.FuelType
+				}
+				v.FuelCapacity[fuel] += int64(p.Capacity)
+			}
+		}
+	}
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}